@@ -0,0 +1,254 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"loomi2.0/core"
+	"loomi2.0/models"
+	"loomi2.0/prompts"
+)
+
+// ActionRequest 行动执行请求
+type ActionRequest struct {
+	Round       int         // 当前 ReAct 轮次
+	Action      string      // 行动名称
+	Instruction string      // 编排器下达的指令
+	Task        string      // 用户原始任务
+	References  []core.Note // 指令中 @ 引用的笔记
+}
+
+// ActionNote 行动产出的单条笔记
+type ActionNote struct {
+	Tag     string // 标签名，例如 insight
+	Index   int    // 标签中的序号，例如 <insight2> 中的 2
+	Content string // 笔记内容
+
+	Sources     []string          // 引用的信息来源（websearch 的 URL）
+	Deliverable *core.Deliverable // 写作类行动的结构化交付物，分析类行动为空
+}
+
+// ActionResult 行动执行结果
+type ActionResult struct {
+	Notes    []ActionNote // 解析出的笔记
+	Raw      string       // 模型原始输出
+	Problems []string     // 没有保存的条目及原因，写入编排器的观察
+}
+
+// ActionExecutor 行动执行器接口
+type ActionExecutor interface {
+	Name() string
+	Description() string
+	Execute(ctx context.Context, req ActionRequest) (*ActionResult, error)
+}
+
+// NoteTagger 产出的笔记类型与行动名称不同的执行器实现该接口，例如 xhs_style 产出 <style数字>
+type NoteTagger interface {
+	Tag() string
+}
+
+// ActionRegistry 行动注册表，把编排器提示词中的 action 名称映射到执行器
+type ActionRegistry struct {
+	mu        sync.RWMutex
+	executors map[string]ActionExecutor
+}
+
+// NewActionRegistry 创建行动注册表
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		executors: make(map[string]ActionExecutor),
+	}
+}
+
+// Register 注册行动执行器，同名执行器会被覆盖
+func (r *ActionRegistry) Register(executor ActionExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executors[executor.Name()] = executor
+}
+
+// Get 获取行动执行器
+func (r *ActionRegistry) Get(name string) (ActionExecutor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	executor, exists := r.executors[name]
+	return executor, exists
+}
+
+// ListActions 列出所有已注册的行动名称（按字母排序）
+func (r *ActionRegistry) ListActions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.executors))
+	for name := range r.executors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NoteTypes 列出所有已注册行动产出的笔记类型（按字母排序）
+func (r *ActionRegistry) NoteTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	types := make([]string, 0, len(r.executors))
+	for _, executor := range r.executors {
+		noteType := executor.Name()
+		if tagger, ok := executor.(NoteTagger); ok {
+			noteType = tagger.Tag()
+		}
+		if !seen[noteType] {
+			seen[noteType] = true
+			types = append(types, noteType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+var actionRegistry = newDefaultActionRegistry()
+
+// newDefaultActionRegistry 创建包含内置行动的注册表
+func newDefaultActionRegistry() *ActionRegistry {
+	registry := NewActionRegistry()
+	registry.Register(NewPromptAction("insight", "insight", "深挖词语背后的情绪与动机", prompts.InsightPrompt))
+	registry.Register(NewPromptAction("profile", "profile", "寻找受众画像及其痛点", prompts.ProfilePrompt))
+	registry.Register(NewPromptAction("hitpoint", "hitpoint", "探索不同视角下的选题打点", prompts.HitpointPrompt))
+	registry.Register(NewPromptAction("xhs_style", "style", "设计小红书文体风格与阅读体验", prompts.XHSStylePrompt))
+	registry.Register(NewBrandAnalysisAction())
+	registry.Register(NewContentAnalysisAction())
+	registry.Register(NewWebSearchAction(""))
+	registry.Register(NewXHSPostAction())
+	registry.Register(NewWechatArticleAction())
+	registry.Register(NewTiktokScriptAction())
+	return registry
+}
+
+// GetActionRegistry 获取全局行动注册表
+func GetActionRegistry() *ActionRegistry {
+	return actionRegistry
+}
+
+// RegisterAction 向全局行动注册表注册执行器
+func RegisterAction(executor ActionExecutor) {
+	actionRegistry.Register(executor)
+}
+
+// PromptAction 基于提示词的行动执行器：构建提示词、调用模型并解析同名标签
+type PromptAction struct {
+	name        string
+	tag         string
+	description string
+	prompt      string
+}
+
+// NewPromptAction 创建基于提示词的行动执行器
+func NewPromptAction(name, tag, description, prompt string) *PromptAction {
+	return &PromptAction{
+		name:        name,
+		tag:         tag,
+		description: description,
+		prompt:      prompt,
+	}
+}
+
+// Name 行动名称
+func (a *PromptAction) Name() string {
+	return a.name
+}
+
+// Tag 产出的笔记类型
+func (a *PromptAction) Tag() string {
+	return a.tag
+}
+
+// Description 行动描述
+func (a *PromptAction) Description() string {
+	return a.description
+}
+
+// Execute 执行行动
+func (a *PromptAction) Execute(ctx context.Context, req ActionRequest) (*ActionResult, error) {
+	modelManager := models.GetModelManager()
+	if modelManager == nil {
+		return nil, fmt.Errorf("模型管理器未初始化")
+	}
+
+	output, err := modelManager.CallCurrentModel(ctx, a.prompt, buildActionUserPrompt(req), nil)
+	if err != nil {
+		return nil, fmt.Errorf("AI 模型调用失败: %w", err)
+	}
+
+	notes := ParseTaggedNotes(output, a.tag)
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("%s 输出中没有找到 <%s数字> 标签", a.name, a.tag)
+	}
+
+	return &ActionResult{Notes: notes, Raw: output}, nil
+}
+
+// buildActionUserPrompt 构建行动执行器的用户提示词
+func buildActionUserPrompt(req ActionRequest) string {
+	var b strings.Builder
+	if req.Task != "" {
+		b.WriteString("## 用户任务\n")
+		b.WriteString(req.Task)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("## 本次指令\n")
+	b.WriteString(req.Instruction)
+	b.WriteString("\n")
+	if len(req.References) > 0 {
+		b.WriteString("\n## 指令中引用的笔记\n")
+		for _, note := range req.References {
+			b.WriteString(note.Render())
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// taggedNoteOpenPattern 笔记标签的开始标签，例如 <insight2>；Go 的正则不支持反向引用，结束标签在匹配后按同一序号查找
+var taggedNoteOpenPattern = regexp.MustCompile(`<([A-Za-z_]+)(\d+)>`)
+
+// ParseTaggedNotes 解析 <tag1>...</tag1> 形式的标签输出，结束标签的序号必须与开始标签一致
+func ParseTaggedNotes(output, tag string) []ActionNote {
+	var notes []ActionNote
+	for rest := output; ; {
+		loc := taggedNoteOpenPattern.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		name, number := rest[loc[2]:loc[3]], rest[loc[4]:loc[5]]
+		body := rest[loc[1]:]
+		end := -1
+		if name == tag {
+			end = strings.Index(body, "</"+tag+number+">")
+		}
+		if end < 0 {
+			rest = body
+			continue
+		}
+		rest = body[end+len("</"+tag+number+">"):]
+
+		content := strings.TrimSpace(body[:end])
+		if content == "" {
+			continue
+		}
+		index, _ := strconv.Atoi(number)
+		notes = append(notes, ActionNote{
+			Tag:     tag,
+			Index:   index,
+			Content: content,
+		})
+	}
+	return notes
+}
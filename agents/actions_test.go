@@ -0,0 +1,155 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"loomi2.0/config"
+	"loomi2.0/core"
+	"loomi2.0/models"
+)
+
+// useFakeModel 把按脚本应答的假模型设为当前模型，测试结束后恢复原来的模型
+func useFakeModel(t *testing.T, script *models.FakeScript) *models.FakeProvider {
+	t.Helper()
+	t.Setenv("LOOMI_DEEPSEEK_API_KEY", "test-key")
+	if err := models.InitModelManager(); err != nil {
+		t.Fatalf("初始化模型管理器失败: %v", err)
+	}
+	manager := models.GetModelManager()
+
+	fake, err := models.NewFakeProvider(config.ProviderConfig{Name: "fake-" + t.Name(), Type: config.ProviderTypeFake}, script)
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+	manager.RegisterProvider(fake)
+
+	previous := models.GetCurrentModelName()
+	if err := manager.SetCurrentProvider(fake.Name()); err != nil {
+		t.Fatalf("设置当前模型失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if previous != "" {
+			manager.SetCurrentProvider(previous)
+		}
+	})
+	return fake
+}
+
+type stubAction struct {
+	name string
+	tag  string
+}
+
+func (a stubAction) Name() string        { return a.name }
+func (a stubAction) Description() string { return "测试行动" }
+func (a stubAction) Tag() string         { return a.tag }
+func (a stubAction) Execute(ctx context.Context, req ActionRequest) (*ActionResult, error) {
+	return &ActionResult{}, nil
+}
+
+func TestActionRegistry(t *testing.T) {
+	registry := NewActionRegistry()
+	registry.Register(NewPromptAction("insight", "insight", "洞察", "提示词"))
+	registry.Register(NewPromptAction("xhs_style", "style", "风格", "提示词"))
+	registry.Register(stubAction{name: "style_copy", tag: "style"})
+
+	if got, want := registry.ListActions(), []string{"insight", "style_copy", "xhs_style"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("行动列表期望 %v，实际 %v", want, got)
+	}
+	// 多个行动产出同一类型的笔记时只列一次
+	if got, want := registry.NoteTypes(), []string{"insight", "style"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("笔记类型期望 %v，实际 %v", want, got)
+	}
+
+	registry.Register(stubAction{name: "insight", tag: "insight"})
+	if executor, ok := registry.Get("insight"); !ok || executor.Description() != "测试行动" {
+		t.Errorf("同名执行器应当被覆盖，实际 %v", executor)
+	}
+	if _, ok := registry.Get("unknown"); ok {
+		t.Error("未注册的行动不应当找到执行器")
+	}
+
+	for _, noteType := range GetActionRegistry().NoteTypes() {
+		if noteType == "xhs_style" {
+			t.Errorf("内置注册表应当使用 xhs_style 的笔记类型 style，实际 %v", GetActionRegistry().NoteTypes())
+		}
+	}
+}
+
+func TestParseTaggedNotes(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []ActionNote
+	}{
+		{
+			name:   "多条笔记",
+			output: "开场白\n<insight1>\n第一条\n</insight1>\n<insight2>第二条</insight2>",
+			want:   []ActionNote{{Tag: "insight", Index: 1, Content: "第一条"}, {Tag: "insight", Index: 2, Content: "第二条"}},
+		},
+		{
+			name:   "结束标签序号不一致",
+			output: "<insight1>没有闭合</insight2><insight3>第三条</insight3>",
+			want:   []ActionNote{{Tag: "insight", Index: 3, Content: "第三条"}},
+		},
+		{
+			name:   "忽略其他标签和空内容",
+			output: "<profile1>画像</profile1><insight1> </insight1><insight2>洞察</insight2>",
+			want:   []ActionNote{{Tag: "insight", Index: 2, Content: "洞察"}},
+		},
+		{
+			name:   "内容中嵌套其他标签",
+			output: "<insight1><title>标题</title>正文</insight1>",
+			want:   []ActionNote{{Tag: "insight", Index: 1, Content: "<title>标题</title>正文"}},
+		},
+		{
+			name:   "没有标签",
+			output: "只有普通文本",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTaggedNotes(tt.output, "insight"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("期望 %+v，实际 %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPromptActionExecute(t *testing.T) {
+	fake := useFakeModel(t, &models.FakeScript{
+		Rules: []*models.FakeRule{
+			{Name: "insight", System: "洞察提示词", Responses: []string{"<insight1>年轻人怕麻烦</insight1>"}},
+			{Name: "empty", System: "空输出", Responses: []string{"没有按格式输出"}},
+		},
+	})
+
+	req := ActionRequest{
+		Action:      "insight",
+		Instruction: "分析 @profile1 的动机",
+		Task:        "写一篇防晒霜帖子",
+		References:  []core.Note{{ID: "profile1", Type: "profile", Index: 1, Content: "通勤白领"}},
+	}
+	result, err := NewPromptAction("insight", "insight", "洞察", "洞察提示词").Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("执行行动失败: %v", err)
+	}
+	if len(result.Notes) != 1 || result.Notes[0].Content != "年轻人怕麻烦" {
+		t.Errorf("应当解析出一条洞察，实际 %+v", result.Notes)
+	}
+
+	user := fake.Calls()[0].User
+	for _, want := range []string{"写一篇防晒霜帖子", "分析 @profile1 的动机", "通勤白领"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("用户提示词应当包含 %q，实际:\n%s", want, user)
+		}
+	}
+
+	_, err = NewPromptAction("insight", "insight", "洞察", "空输出").Execute(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "<insight数字>") {
+		t.Errorf("没有标签时应当报错，实际 %v", err)
+	}
+}
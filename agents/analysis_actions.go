@@ -0,0 +1,59 @@
+package agents
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"loomi2.0/core"
+	"loomi2.0/prompts"
+)
+
+// pastedMaterialMinRunes 指令长度超过该值且没有引用任何笔记时，视为直接粘贴了待拆解的内容
+const pastedMaterialMinRunes = 120
+
+// ContentAnalysisAction 社媒内容分析行动，支持引用笔记或直接粘贴原文
+type ContentAnalysisAction struct {
+	*PromptAction
+}
+
+// NewBrandAnalysisAction 创建品牌分析行动
+func NewBrandAnalysisAction() *PromptAction {
+	return NewPromptAction("brand_analysis", "brand_analysis", "从用户角度分析并对比1～3个品牌", prompts.BrandAnalysisPrompt)
+}
+
+// NewContentAnalysisAction 创建社媒内容分析行动
+func NewContentAnalysisAction() *ContentAnalysisAction {
+	return &ContentAnalysisAction{
+		PromptAction: NewPromptAction("content_analysis", "content_analysis", "拆解一篇社媒内容的质量与技巧", prompts.ContentAnalysisPrompt),
+	}
+}
+
+// isPastedMaterial 指令是否长到可能是直接粘贴的原文
+func isPastedMaterial(instruction string) bool {
+	return utf8.RuneCountInString(instruction) >= pastedMaterialMinRunes
+}
+
+// ResolveReferences 直接粘贴的原文中常有 @用户名 等内容，没有引用到已有笔记时不报错，按粘贴的材料处理
+func (a *ContentAnalysisAction) ResolveReferences(instruction string, workspace *core.WorkSpace) ([]core.Note, error) {
+	references, err := ResolveReferences(instruction, workspace)
+	if isPastedMaterial(instruction) && (err != nil || len(references) == 0) {
+		return nil, nil
+	}
+	return references, err
+}
+
+// Execute 执行内容分析；直接粘贴的原文会同时保存为 material 笔记，便于后续步骤 @引用
+func (a *ContentAnalysisAction) Execute(ctx context.Context, req ActionRequest) (*ActionResult, error) {
+	result, err := a.PromptAction.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.References) == 0 && isPastedMaterial(req.Instruction) {
+		result.Notes = append([]ActionNote{{
+			Tag:     core.MaterialNoteType,
+			Content: req.Instruction,
+		}}, result.Notes...)
+	}
+	return result, nil
+}
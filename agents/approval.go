@@ -0,0 +1,168 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"loomi2.0/core"
+)
+
+// ApprovalDecision 用户对写作行动提议的决定
+type ApprovalDecision string
+
+const (
+	ApprovalApprove  ApprovalDecision = "approve"  // 按原指令执行
+	ApprovalEdit     ApprovalDecision = "edit"     // 修改指令后执行
+	ApprovalRedirect ApprovalDecision = "redirect" // 不执行，给编排器新的方向
+)
+
+// ApprovalRequest 等待用户审批的写作行动提议
+type ApprovalRequest struct {
+	JobID       string
+	Round       int
+	Action      string
+	Instruction string
+	Tactics     string      // 编排器本轮的战术备忘
+	References  []core.Note // 指令中 @ 引用的笔记
+}
+
+// String 渲染提议，供 CLI 展示
+func (r *ApprovalRequest) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Round%d 提议执行 '%s'\n", r.Round, r.Action))
+	if r.Tactics != "" {
+		b.WriteString(fmt.Sprintf("战术: %s\n", r.Tactics))
+	}
+	b.WriteString(fmt.Sprintf("指令: %s\n", r.Instruction))
+	for _, note := range r.References {
+		b.WriteString(fmt.Sprintf("引用 @%s: %s\n", note.ID, truncateRunes(note.Content, 120)))
+	}
+	return b.String()
+}
+
+// ApprovalResponse 用户的审批结果
+type ApprovalResponse struct {
+	Decision    ApprovalDecision
+	Instruction string // edit 时的新指令
+	Feedback    string // redirect 时给编排器的新方向
+}
+
+// pendingApproval 正在等待的审批
+type pendingApproval struct {
+	request  *ApprovalRequest
+	response chan ApprovalResponse
+}
+
+// SetApprovalMode 开启或关闭审批模式：开启后编排器在执行写作行动前暂停，等待用户审批
+func (o *Orchestrator) SetApprovalMode(enabled bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.approvalMode = enabled
+}
+
+// ApprovalMode 检查是否开启了审批模式
+func (o *Orchestrator) ApprovalMode() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.approvalMode
+}
+
+// ApprovalRequests 编排器暂停等待审批时推送提议的通道
+func (o *Orchestrator) ApprovalRequests() <-chan *ApprovalRequest {
+	return o.approvalRequests
+}
+
+// PendingApproval 获取正在等待审批的提议，没有时返回 nil
+func (o *Orchestrator) PendingApproval() *ApprovalRequest {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.pending == nil {
+		return nil
+	}
+	return o.pending.request
+}
+
+// ResolveApproval 提交审批结果，编排器随后继续执行
+func (o *Orchestrator) ResolveApproval(response ApprovalResponse) error {
+	switch response.Decision {
+	case ApprovalApprove:
+	case ApprovalEdit:
+		if strings.TrimSpace(response.Instruction) == "" {
+			return fmt.Errorf("修改后的指令为空")
+		}
+	case ApprovalRedirect:
+		if strings.TrimSpace(response.Feedback) == "" {
+			return fmt.Errorf("新的方向为空")
+		}
+	default:
+		return fmt.Errorf("未知的审批决定: %s", response.Decision)
+	}
+
+	o.mu.Lock()
+	pending := o.pending
+	o.pending = nil
+	o.mu.Unlock()
+	if pending == nil {
+		return fmt.Errorf("没有等待审批的提议")
+	}
+
+	pending.response <- response
+	return nil
+}
+
+// needsApproval 检查行动是否需要审批：审批模式下的写作类行动
+func (o *Orchestrator) needsApproval(action string) bool {
+	if !o.ApprovalMode() {
+		return false
+	}
+	executor, exists := GetActionRegistry().Get(action)
+	if !exists {
+		return false
+	}
+	_, isWriting := executor.(*WritingAction)
+	return isWriting
+}
+
+// awaitApproval 发布提议并阻塞等待用户审批，任务被取消时返回错误
+func (o *Orchestrator) awaitApproval(ctx context.Context, round int, step ReActStep) (ApprovalResponse, error) {
+	request := &ApprovalRequest{
+		Round:       round,
+		Action:      step.Action,
+		Instruction: step.Instruction,
+		Tactics:     step.Tactics,
+	}
+	if job := orchestratorJobFromContext(ctx); job != nil {
+		request.JobID = job.ID
+	}
+	// 引用无法解析时仍然展示提议，执行阶段会报告具体错误
+	if references, err := resolveActionReferences(step.Action, step.Instruction, o.workspace); err == nil {
+		request.References = references
+	}
+
+	pending := &pendingApproval{
+		request:  request,
+		response: make(chan ApprovalResponse, 1),
+	}
+	o.mu.Lock()
+	o.pending = pending
+	o.mu.Unlock()
+
+	// 非阻塞通知，没有监听者时提议仍可通过 PendingApproval 获取
+	select {
+	case o.approvalRequests <- request:
+	default:
+	}
+
+	select {
+	case response := <-pending.response:
+		return response, nil
+	case <-ctx.Done():
+		o.mu.Lock()
+		if o.pending == pending {
+			o.pending = nil
+		}
+		o.mu.Unlock()
+		return ApprovalResponse{}, fmt.Errorf("等待审批时任务已取消: %v", ctx.Err())
+	}
+}
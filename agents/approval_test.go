@@ -0,0 +1,237 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"loomi2.0/core"
+	"loomi2.0/models"
+)
+
+// approvalTestScript 编排器第1轮提议写小红书帖子，第2轮完成任务
+func approvalTestScript() *models.FakeScript {
+	return &models.FakeScript{
+		Rules: []*models.FakeRule{
+			{Name: "orchestrator", System: "Orchestrator（编排员）", Responses: []string{
+				"<tactics>直接写帖子</tactics>\n<execute_step action=\"xhs_post\" instruction=\"写一篇防晒霜帖子\"/>",
+				"<task_completed/>",
+			}},
+			{Name: "xhs_post", System: "资深的小红书博主", Responses: []string{"<xhs_post1>\n<title>通勤防晒</title>\n<body>正文</body>\n</xhs_post1>"}},
+		},
+	}
+}
+
+// waitApproval 等待编排器发布审批提议
+func waitApproval(t *testing.T, o *Orchestrator) *ApprovalRequest {
+	t.Helper()
+	select {
+	case request := <-o.ApprovalRequests():
+		return request
+	case <-time.After(time.Second):
+		t.Fatal("编排器没有发布审批提议")
+		return nil
+	}
+}
+
+// waitJob 等待后台任务结束
+func waitJob(t *testing.T, job *OrchestratorJob) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := job.Wait(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("任务没有结束")
+	}
+	return result, err
+}
+
+// fakeCallsByRule 按规则名称筛选假模型收到的调用
+func fakeCallsByRule(fake *models.FakeProvider, rule string) []models.FakeCall {
+	var calls []models.FakeCall
+	for _, call := range fake.Calls() {
+		if call.Rule == rule {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestApprovalDecisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  ApprovalResponse
+		wantWrite string // 写作行动收到的指令，为空表示不执行写作
+		wantPlan  string // 第2轮编排时观察中应当包含的内容
+	}{
+		{
+			name:      "批准",
+			response:  ApprovalResponse{Decision: ApprovalApprove},
+			wantWrite: "写一篇防晒霜帖子",
+			wantPlan:  "Round1 执行 'xhs_post' 的结果",
+		},
+		{
+			name:      "修改指令",
+			response:  ApprovalResponse{Decision: ApprovalEdit, Instruction: " 写一篇面向学生党的防晒霜帖子 "},
+			wantWrite: "写一篇面向学生党的防晒霜帖子",
+			wantPlan:  "Round1 执行 'xhs_post' 的结果",
+		},
+		{
+			name:     "改变方向",
+			response: ApprovalResponse{Decision: ApprovalRedirect, Feedback: "先做受众画像"},
+			wantPlan: "用户没有采纳 Round1 提议的 'xhs_post'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeModel(t, approvalTestScript())
+			o := newTestOrchestrator(t)
+			o.SetApprovalMode(true)
+
+			job, err := o.StartTask("写一篇防晒霜帖子")
+			if err != nil {
+				t.Fatalf("启动任务失败: %v", err)
+			}
+			request := waitApproval(t, o)
+			if request.JobID != job.ID || request.Round != 1 || request.Action != "xhs_post" || request.Tactics != "直接写帖子" {
+				t.Errorf("审批提议错误: %+v", request)
+			}
+			if o.PendingApproval() != request {
+				t.Error("等待审批时应当能获取到提议")
+			}
+
+			if err := o.ResolveApproval(tt.response); err != nil {
+				t.Fatalf("提交审批结果失败: %v", err)
+			}
+			if _, err := waitJob(t, job); err != nil {
+				t.Fatalf("任务执行失败: %v", err)
+			}
+			if o.PendingApproval() != nil {
+				t.Error("审批后不应再有等待的提议")
+			}
+
+			writes := fakeCallsByRule(fake, "xhs_post")
+			switch {
+			case tt.wantWrite == "" && len(writes) != 0:
+				t.Errorf("改变方向时不应执行写作，实际调用 %d 次", len(writes))
+			case tt.wantWrite != "" && (len(writes) != 1 || !strings.Contains(writes[0].User, "## 本次指令\n"+tt.wantWrite+"\n")):
+				t.Errorf("写作行动应当收到指令 %q，实际 %+v", tt.wantWrite, writes)
+			}
+
+			plans := fakeCallsByRule(fake, "orchestrator")
+			if len(plans) != 2 || !strings.Contains(plans[1].User, tt.wantPlan) {
+				t.Errorf("第2轮编排的观察应当包含 %q，实际 %+v", tt.wantPlan, plans)
+			}
+			if tt.response.Decision == ApprovalRedirect {
+				if tasks := o.workspace.GetTasks(); tasks[len(tasks)-1] != "先做受众画像" {
+					t.Errorf("新的方向应当写入任务，实际 %v", tasks)
+				}
+				if !strings.Contains(plans[1].User, "先做受众画像 (新)") {
+					t.Errorf("新的方向应当出现在时间线中，实际:\n%s", plans[1].User)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveApprovalErrors(t *testing.T) {
+	o := newTestOrchestrator(t)
+
+	tests := []struct {
+		name     string
+		response ApprovalResponse
+		want     string
+	}{
+		{name: "修改后的指令为空", response: ApprovalResponse{Decision: ApprovalEdit, Instruction: " "}, want: "修改后的指令为空"},
+		{name: "新的方向为空", response: ApprovalResponse{Decision: ApprovalRedirect}, want: "新的方向为空"},
+		{name: "未知的决定", response: ApprovalResponse{Decision: "skip"}, want: "未知的审批决定: skip"},
+		{name: "没有等待的提议", response: ApprovalResponse{Decision: ApprovalApprove}, want: "没有等待审批的提议"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := o.ResolveApproval(tt.response); err == nil || err.Error() != tt.want {
+				t.Errorf("期望错误 %q，实际 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestApprovalCancelled(t *testing.T) {
+	fake := useFakeModel(t, approvalTestScript())
+	o := newTestOrchestrator(t)
+	o.SetApprovalMode(true)
+
+	job, err := o.StartTask("写一篇防晒霜帖子")
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	waitApproval(t, o)
+	job.Cancel()
+
+	if _, err := waitJob(t, job); err == nil || !strings.Contains(err.Error(), "等待审批时任务已取消") {
+		t.Errorf("等待审批时取消应当返回错误，实际 %v", err)
+	}
+	if job.Status() != JobStatusCancelled || o.PendingApproval() != nil {
+		t.Errorf("取消后任务状态 %s，等待的提议 %v", job.Status(), o.PendingApproval())
+	}
+	if writes := fakeCallsByRule(fake, "xhs_post"); len(writes) != 0 {
+		t.Errorf("取消后不应执行写作，实际调用 %d 次", len(writes))
+	}
+}
+
+func TestInjectFeedbackMidRound(t *testing.T) {
+	fake := useFakeModel(t, approvalTestScript())
+	o := newTestOrchestrator(t)
+
+	if err := o.InjectFeedback("加上价格"); err == nil {
+		t.Error("没有运行中的任务时注入反馈应当报错")
+	}
+
+	// 用审批暂停第1轮，在这一轮执行中途注入反馈
+	o.SetApprovalMode(true)
+	job, err := o.StartTask("写一篇防晒霜帖子")
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	waitApproval(t, o)
+	if err := o.InjectFeedback("  "); err == nil {
+		t.Error("空反馈应当报错")
+	}
+	for _, feedback := range []string{"加上价格", "语气轻松一点"} {
+		if err := o.InjectFeedback(feedback); err != nil {
+			t.Fatalf("注入反馈失败: %v", err)
+		}
+	}
+	if err := o.ResolveApproval(ApprovalResponse{Decision: ApprovalApprove}); err != nil {
+		t.Fatalf("提交审批结果失败: %v", err)
+	}
+	if _, err := waitJob(t, job); err != nil {
+		t.Fatalf("任务执行失败: %v", err)
+	}
+
+	// 第1轮照常执行，反馈在第2轮编排时一并读取，之后不再重复提示
+	plans := fakeCallsByRule(fake, "orchestrator")
+	if len(plans) != 2 {
+		t.Fatalf("应当编排 2 轮，实际 %d", len(plans))
+	}
+	if strings.Contains(plans[0].User, "加上价格") {
+		t.Error("第1轮编排时反馈还没有注入")
+	}
+	round2 := plans[1].User
+	for _, want := range []string{"用户在执行中补充了2条新需求", "加上价格\n", "语气轻松一点 (新)", "Round1 执行 'xhs_post' 的结果"} {
+		if !strings.Contains(round2, want) {
+			t.Errorf("第2轮编排应当包含 %q，实际:\n%s", want, round2)
+		}
+	}
+	if got := job.takeFeedback(); len(got) != 0 {
+		t.Errorf("反馈被读取后应当清空，实际 %v", got)
+	}
+	if tasks := o.workspace.GetTasks(); len(tasks) != 3 {
+		t.Errorf("反馈应当写入任务，实际 %v", tasks)
+	}
+	if messages := o.conversation.GetMessagesByRole(core.MessageRoleTask); len(messages) != 3 {
+		t.Errorf("反馈应当进入任务时间线，实际 %d 条", len(messages))
+	}
+}
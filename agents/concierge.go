@@ -6,12 +6,10 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"loomi2.0/core"
 	"loomi2.0/models"
-	"loomi2.0/prompts"
 	"loomi2.0/tools"
 )
 
@@ -21,7 +19,6 @@ type Concierge struct {
 	conversation *core.ConversationManager
 	graph        *compose.Graph[[]*schema.Message, *schema.Message]
 	compiledGraph compose.Runnable[[]*schema.Message, *schema.Message]
-	currentInput  string
 	conversationHistory []string // 添加对话历史
 	toolManager  *tools.ToolManager // 添加工具管理器
 }
@@ -36,21 +33,26 @@ func InitConcierge() error {
 		workspace := core.GetWorkspace()
 		conversation := core.GetConversationManager()
 		
-		// 初始化工具管理器
-		toolManager := tools.NewToolManager()
-		
-		// 注册搜索工具
-		serperTool := tools.NewSerperTool("your-serper-api-key")
-		tavilyTool := tools.NewTavilyTool("your-tavily-api-key")
-		
-		toolManager.RegisterTool(serperTool)
-		toolManager.RegisterTool(tavilyTool)
+		// 初始化工具管理器（与编排器的 websearch 行动共用）
+		if err = tools.InitToolManager(); err != nil {
+			return
+		}
+		toolManager := tools.GetToolManager()
 		
 		concierge = &Concierge{
 			workspace:    workspace,
 			conversation: conversation,
 			toolManager:  toolManager,
 		}
+		// 恢复的会话中已有对话时，重建对话历史
+		for _, msg := range conversation.GetMessages() {
+			switch msg.Role {
+			case "user":
+				concierge.conversationHistory = append(concierge.conversationHistory, "用户: "+msg.Content)
+			case "assistant":
+				concierge.conversationHistory = append(concierge.conversationHistory, "助手: "+msg.Content)
+			}
+		}
 		err = concierge.init()
 	})
 	return err
@@ -69,82 +71,39 @@ func (c *Concierge) init() error {
 	return nil
 }
 
-func (c *Concierge) buildGraph() error {
-	// 暂时跳过图的构建，直接返回成功
-	// TODO: 修复 eino Graph 的类型匹配问题
-	return nil
-}
-
-func (c *Concierge) createInputComponent() model.BaseChatModel {
-	return &ConciergeInputComponent{
-		concierge: c,
+// ProcessUserInput 处理用户输入
+func (c *Concierge) ProcessUserInput(ctx context.Context, userInput string) (string, error) {
+	if c.compiledGraph == nil {
+		return "", fmt.Errorf("门房编排图未编译")
 	}
-}
 
-func (c *Concierge) createIntentComponent() model.BaseChatModel {
-	return &ConciergeIntentComponent{
-		concierge: c,
+	response, err := c.compiledGraph.Invoke(ctx, []*schema.Message{schema.UserMessage(userInput)})
+	if err != nil {
+		return "", err
 	}
+	return response.Content, nil
 }
 
-func (c *Concierge) createResponseComponent() model.BaseChatModel {
-	return &ConciergeResponseComponent{
-		concierge: c,
+// ProcessUserInputStream 以流式方式处理用户输入：门房编排图照常执行，模型输出经 ctx 中的流式回调逐段写入返回的流。
+// 与 Orchestrator.ProcessTaskStream 相同，模型在分支节点内部调用，compiledGraph.Stream 只能在响应节点完成后输出整段回复，
+// 所以用 Invoke 执行编排图；非模型回复由响应节点经同一个回调补发
+func (c *Concierge) ProcessUserInputStream(ctx context.Context, userInput string) (*schema.StreamReader[*schema.Message], error) {
+	if c.compiledGraph == nil {
+		return nil, fmt.Errorf("门房编排图未编译")
 	}
-}
-
-// ProcessUserInput 处理用户输入
-func (c *Concierge) ProcessUserInput(ctx context.Context, userInput string) (string, error) {
-	c.currentInput = userInput
-	
-	// 添加用户消息到对话历史
-	c.conversation.AddMessage("user", userInput)
 
-	// 暂时直接处理用户输入，跳过 eino 编排图
-	// TODO: 修复 eino Graph 的类型匹配问题后恢复
-	
-	// 记录用户输入到对话历史
-	c.conversationHistory = append(c.conversationHistory, "用户: "+userInput)
-	
-	// 检查是否是搜索确认
-	var response string
-	if c.isSearchConfirmation(userInput) {
-		response = c.executeSearch()
-	} else {
-		// 简单的意图识别和响应生成
-		response = c.generateResponse(userInput)
-	}
-	
-	// 记录助手响应到对话历史
-	c.conversationHistory = append(c.conversationHistory, "助手: "+response)
-	
-	// 添加助手消息到对话历史
-	c.conversation.AddMessage("assistant", response)
-	return response, nil
-}
+	reader, writer := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer writer.Close()
 
-// generateResponse 生成响应
-func (c *Concierge) generateResponse(userInput string) string {
-	// 检查是否是确认性回复
-	if c.isConfirmationResponse(userInput) {
-		// 用户确认了需求，启动 Orchestrator
-		return c.startOrchestrator(userInput)
-	}
-	
-	// 检查是否是搜索意图
-	if isSearch, query := c.toolManager.DetectSearchIntent(userInput); isSearch {
-		return c.handleSearchRequest(query)
-	}
-	
-	// 调用 AI 模型生成响应
-	response, err := c.callAIModel(userInput)
-	if err != nil {
-		// 如果 AI 调用失败，返回默认响应
-		fmt.Printf("AI 调用失败: %v\n", err)
-		return c.generateGeneralResponse()
-	}
-	fmt.Printf("AI 调用成功，响应: %s\n", response)
-	return response
+		streamCtx := models.WithStreamHandler(ctx, func(chunk string) {
+			writer.Send(schema.AssistantMessage(chunk, nil), nil)
+		})
+		if _, err := c.compiledGraph.Invoke(streamCtx, []*schema.Message{schema.UserMessage(userInput)}); err != nil {
+			writer.Send(nil, err)
+		}
+	}()
+	return reader, nil
 }
 
 // handleSearchRequest 处理搜索请求
@@ -179,7 +138,7 @@ func (c *Concierge) isConfirmationResponse(userInput string) bool {
 	return false
 }
 
-// startOrchestrator 启动 Orchestrator 生成内容
+// startOrchestrator 把确认后的需求交给 Orchestrator：没有任务运行时在后台启动新任务，否则作为新需求注入当前任务
 func (c *Concierge) startOrchestrator(userInput string) string {
 	// 获取 Orchestrator 实例
 	orchestrator := GetOrchestrator()
@@ -187,16 +146,81 @@ func (c *Concierge) startOrchestrator(userInput string) string {
 		return "抱歉，编排器暂时不可用，请稍后再试。"
 	}
 	
+	if orchestrator.IsRunning() {
+		return c.forwardToOrchestrator(c.buildFeedbackDescription(userInput))
+	}
+	
 	// 构建任务描述
 	taskDescription := c.buildTaskDescription()
 	
-	// 调用 Orchestrator 处理任务
-	response, err := orchestrator.ProcessTask(context.Background(), taskDescription)
+	job, err := orchestrator.StartTask(taskDescription)
 	if err != nil {
-		return fmt.Sprintf("任务处理失败: %v", err)
+		return fmt.Sprintf("任务启动失败: %v", err)
 	}
 	
-	return response
+	return fmt.Sprintf("好的，已经开始处理（任务 %s），完成后会把结果发给你。执行过程中有新的想法可以随时告诉我。", job.ID)
+}
+
+// forwardToOrchestrator 把需求传递给 Orchestrator，返回给用户的回复
+func (c *Concierge) forwardToOrchestrator(requirement string) string {
+	orchestrator := GetOrchestrator()
+	if orchestrator == nil {
+		return "抱歉，编排器暂时不可用，请稍后再试。"
+	}
+	
+	if !orchestrator.IsRunning() {
+		job, err := orchestrator.StartTask(requirement)
+		if err != nil {
+			return fmt.Sprintf("任务启动失败: %v", err)
+		}
+		return fmt.Sprintf("好的，已经开始处理（任务 %s），完成后会把结果发给你。", job.ID)
+	}
+	
+	if err := orchestrator.InjectFeedback(requirement); err != nil {
+		return fmt.Sprintf("新需求传递失败: %v", err)
+	}
+	return "收到，已经把新的需求补充进当前任务，接下来的步骤会按这个调整。"
+}
+
+// buildFeedbackDescription 构建任务执行中的新需求描述：用户确认前的上一条消息加上确认内容
+func (c *Concierge) buildFeedbackDescription(userInput string) string {
+	// 最后一条是当前输入，向前找上一条用户消息
+	previous := ""
+	for i := len(c.conversationHistory) - 2; i >= 0; i-- {
+		if strings.HasPrefix(c.conversationHistory[i], "用户: ") {
+			previous = strings.TrimPrefix(c.conversationHistory[i], "用户: ")
+			break
+		}
+	}
+	if previous == "" {
+		return userInput
+	}
+	return fmt.Sprintf("%s\n（用户确认：%s）", previous, userInput)
+}
+
+// buildOrchestratorTimeline 构建 ConciergePrompt 中的 [Orchestrator任务执行时间线]，包括任务消息、执行记录和系统状态
+func (c *Concierge) buildOrchestratorTimeline() string {
+	orchestrator := GetOrchestrator()
+	if orchestrator == nil {
+		return ""
+	}
+	
+	status := "空闲，等待新需求"
+	if job := orchestrator.CurrentJob(); job != nil {
+		status = fmt.Sprintf("任务 %s %s", job.ID, job.Status())
+		if job.IsRunning() {
+			status += "，新的需求需要确认后传递"
+		}
+	}
+	
+	var b strings.Builder
+	b.WriteString("[Orchestrator任务执行时间线]\n")
+	b.WriteString(orchestrator.contextBuilder.BuildTimeline())
+	b.WriteString(orchestrator.contextBuilder.BuildTactics())
+	b.WriteString("系统状态: ")
+	b.WriteString(status)
+	b.WriteString("\n")
+	return b.String()
 }
 
 // buildTaskDescription 构建任务描述
@@ -227,21 +251,26 @@ func (c *Concierge) buildTaskDescription() string {
 }
 
 // callAIModel 调用 AI 模型
-func (c *Concierge) callAIModel(userInput string) (string, error) {
-	// 构建 system prompt 和 user prompt
-	systemPrompt := c.buildConciergeSystemPrompt()
-	userPrompt := userInput
-	
+func (c *Concierge) callAIModel(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// 调用模型管理器
 	modelManager := models.GetModelManager()
 	if modelManager == nil {
 		return "", fmt.Errorf("模型管理器未初始化")
 	}
 	
+	ctx = models.WithUsageTags(ctx, models.UsageTags{Session: c.conversation.GetSessionID(), Agent: "concierge"})
+
+	// 流式输出时隐藏 <call_orchestrator> 工具调用，它会在响应节点中转交给编排器
+	if handler := models.StreamHandlerFromContext(ctx); handler != nil {
+		filter := newHiddenTagFilter("<call_orchestrator>", "</call_orchestrator>", handler)
+		ctx = models.WithStreamHandler(ctx, filter.Write)
+		defer filter.Flush()
+	}
+
 	// 调用当前模型
-	response, err := modelManager.CallCurrentModel(context.Background(), systemPrompt, userPrompt, nil)
+	response, err := modelManager.CallCurrentModel(ctx, systemPrompt, userPrompt, nil)
 	if err != nil {
-		return "", fmt.Errorf("AI 模型调用失败: %v", err)
+		return "", fmt.Errorf("AI 模型调用失败: %w", err)
 	}
 	
 	return response, nil
@@ -272,272 +301,6 @@ func (c *Concierge) buildConciergeSystemPrompt() string {
 
 
 
-// generateHelpResponse 生成帮助响应
-func (c *Concierge) generateHelpResponse() string {
-	return `欢迎使用 Loomi 2.0！
-
-我可以帮助您：
-1. 查看系统状态 - 输入 "status" 或 "状态"
-2. 管理笔记 - 输入 "note" 或 "笔记"
-3. 处理任务 - 输入 "task" 或 "任务"
-4. 获取帮助 - 输入 "help" 或 "帮助"
-
-请告诉我您需要什么帮助？`
-}
-
-// generateStatusResponse 生成状态响应
-func (c *Concierge) generateStatusResponse() string {
-	return `系统状态：
-✅ 模型管理器：已初始化
-✅ 工作空间：已初始化
-✅ 对话管理器：已初始化
-✅ 门房智能体：已初始化
-✅ 编排器智能体：已初始化
-
-系统运行正常！`
-}
-
-// generateNoteResponse 生成笔记响应
-func (c *Concierge) generateNoteResponse() string {
-	return `笔记管理功能：
-📝 创建笔记
-📝 查看笔记
-📝 编辑笔记
-📝 删除笔记
-
-请告诉我您想要进行哪种操作？`
-}
-
-// generateTaskResponse 生成任务响应
-func (c *Concierge) generateTaskResponse() string {
-	return `任务处理功能：
-🎯 任务分析
-🎯 任务分解
-🎯 任务执行
-🎯 结果汇总
-
-请告诉我您想要处理什么任务？`
-}
-
-// generateGeneralResponse 生成通用响应
-func (c *Concierge) generateGeneralResponse() string {
-	return `您好！我是 Loomi 2.0 的智能助手。
-
-我可以帮助您：
-- 查看系统状态
-- 管理笔记
-- 处理复杂任务
-- 提供智能对话
-
-请告诉我您需要什么帮助？`
-}
-
-// ConciergeInputComponent 门房输入组件
-type ConciergeInputComponent struct {
-	concierge *Concierge
-}
-
-func (c *ConciergeInputComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	// 处理输入，提取用户意图
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	
-	content := input[0].Content
-	
-	// 简单的意图识别
-	var intent string
-	if strings.Contains(content, "帮助") || strings.Contains(content, "help") {
-		intent = "help"
-	} else if strings.Contains(content, "状态") || strings.Contains(content, "status") {
-		intent = "status"
-	} else if strings.Contains(content, "笔记") || strings.Contains(content, "note") {
-		intent = "note"
-	} else if strings.Contains(content, "任务") || strings.Contains(content, "task") {
-		intent = "task"
-	} else {
-		intent = "general"
-	}
-
-	return &schema.Message{
-		Role:    "system",
-		Content: fmt.Sprintf("意图: %s, 内容: %s", intent, content),
-	}, nil
-}
-
-func (c *ConciergeInputComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	// 流式处理输入
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
-
-// ConciergeIntentComponent 门房意图组件
-type ConciergeIntentComponent struct {
-	concierge *Concierge
-}
-
-func (c *ConciergeIntentComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	// 分析意图并生成响应
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	
-	content := input[0].Content
-	
-	// 根据意图生成响应
-	var response string
-	if strings.Contains(content, "意图: help") {
-		response = c.generateHelpResponse()
-	} else if strings.Contains(content, "意图: status") {
-		response = c.generateStatusResponse()
-	} else if strings.Contains(content, "意图: note") {
-		response = c.generateNoteResponse()
-	} else if strings.Contains(content, "意图: task") {
-		response = c.generateTaskResponse()
-	} else {
-		response = c.generateGeneralResponse()
-	}
-
-	return &schema.Message{
-		Role:    "assistant",
-		Content: response,
-	}, nil
-}
-
-func (c *ConciergeIntentComponent) generateHelpResponse() string {
-	return `我是Loomi的门房，可以为您提供以下服务：
-
-1. 帮助信息 - 输入"帮助"或"help"
-2. 系统状态 - 输入"状态"或"status"  
-3. 笔记管理 - 输入"笔记"或"note"
-4. 任务管理 - 输入"任务"或"task"
-5. 一般对话 - 直接输入您的问题
-
-请告诉我您需要什么帮助？`
-}
-
-func (c *ConciergeIntentComponent) generateStatusResponse() string {
-	workspace := c.concierge.workspace
-	conversation := c.concierge.conversation
-	
-	return fmt.Sprintf(`系统状态：
-
-工作空间：
-%s
-
-对话历史：
-%s
-
-当前模型：%s`, 
-		workspace.GetSummary(),
-		conversation.GetConversationSummary(),
-		models.GetCurrentModelName())
-}
-
-func (c *ConciergeIntentComponent) generateNoteResponse() string {
-	notes := c.concierge.workspace.GetNotes()
-	if len(notes) == 0 {
-		return "目前没有保存的笔记。您可以告诉我需要记录什么内容。"
-	}
-	
-	response := "当前笔记：\n"
-	for i, note := range notes {
-		response += fmt.Sprintf("%d. %s\n", i+1, note)
-	}
-	return response
-}
-
-func (c *ConciergeIntentComponent) generateTaskResponse() string {
-	tasks := c.concierge.workspace.GetTasks()
-	if len(tasks) == 0 {
-		return "目前没有待办任务。您可以告诉我需要添加什么任务。"
-	}
-	
-	response := "当前任务：\n"
-	for i, task := range tasks {
-		response += fmt.Sprintf("%d. %s\n", i+1, task)
-	}
-	return response
-}
-
-func (c *ConciergeIntentComponent) generateGeneralResponse() string {
-	// 使用提示词生成响应
-	modelManager := models.GetModelManager()
-	if modelManager == nil {
-		return "我是Loomi，您的AI助手。我可以帮助您进行内容创作、任务分析和智能对话。请告诉我您需要什么帮助？"
-	}
-
-	// 构建包含提示词的上下文
-	context := fmt.Sprintf("%s\n\n用户输入: %s", prompts.ConciergePrompt, c.concierge.currentInput)
-	
-	// 调用模型生成响应
-	response, err := modelManager.CallLLM(context)
-	if err != nil {
-		return "我是Loomi，您的AI助手。我可以帮助您进行内容创作、任务分析和智能对话。请告诉我您需要什么帮助？"
-	}
-	
-	return response
-}
-
-func (c *ConciergeIntentComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
-
-// ConciergeResponseComponent 门房响应组件
-type ConciergeResponseComponent struct {
-	concierge *Concierge
-}
-
-func (c *ConciergeResponseComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	// 最终响应处理
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	return input[0], nil
-}
-
-func (c *ConciergeResponseComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
-
 // isSearchConfirmation 检查是否是搜索确认
 func (c *Concierge) isSearchConfirmation(userInput string) bool {
 	confirmationKeywords := []string{
@@ -555,7 +318,7 @@ func (c *Concierge) isSearchConfirmation(userInput string) bool {
 }
 
 // executeSearch 执行搜索
-func (c *Concierge) executeSearch() string {
+func (c *Concierge) executeSearch(ctx context.Context) string {
 	// 从对话历史中提取搜索查询
 	query := c.extractSearchQueryFromHistory()
 	if query == "" {
@@ -563,7 +326,7 @@ func (c *Concierge) executeSearch() string {
 	}
 	
 	// 执行双重搜索
-	result, err := c.toolManager.PerformDualSearch(context.Background(), query)
+	result, err := c.toolManager.PerformDualSearch(ctx, query)
 	if err != nil {
 		return fmt.Sprintf("❌ 搜索执行失败: %v", err)
 	}
@@ -0,0 +1,291 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/models"
+	"loomi2.0/prompts"
+)
+
+// ConciergeIntent 门房识别出的用户意图
+type ConciergeIntent string
+
+const (
+	ConciergeIntentChat    ConciergeIntent = "chat"    // 一般对话
+	ConciergeIntentClarify ConciergeIntent = "clarify" // 确认内容需求
+	ConciergeIntentSearch  ConciergeIntent = "search"  // 搜索请求或搜索确认
+	ConciergeIntentHandoff ConciergeIntent = "handoff" // 用户确认需求，交给编排器
+)
+
+// 门房编排图节点名称
+const (
+	conciergeNodeInput    = "input"
+	conciergeNodeIntent   = "intent"
+	conciergeNodeChat     = "chat"
+	conciergeNodeClarify  = "clarify"
+	conciergeNodeSearch   = "search"
+	conciergeNodeHandoff  = "handoff"
+	conciergeNodeResponse = "response"
+)
+
+// ConciergeState 门房编排图节点之间传递的状态
+type ConciergeState struct {
+	Input    string          // 用户输入
+	Intent   ConciergeIntent // intent 节点识别出的意图
+	Response string          // 分支节点生成的回复
+	Streamed bool            // 回复已经通过流式回调输出
+}
+
+// contentRequestKeywords 内容生产类需求的关键词，命中时先向用户确认需求
+var contentRequestKeywords = []string{
+	"写", "文案", "小红书", "帖子", "笔记", "公众号", "文章", "抖音", "脚本", "口播", "选题", "种草", "推广", "涨粉",
+}
+
+// callOrchestratorPattern 匹配 ConciergePrompt 约定的 <call_orchestrator> 工具调用
+var callOrchestratorPattern = regexp.MustCompile(`(?s)<call_orchestrator>(.*?)</call_orchestrator>`)
+
+// buildGraph 构建门房编排图：input -> intent -> {chat | clarify | search | handoff} -> response
+func (c *Concierge) buildGraph() error {
+	graph := compose.NewGraph[[]*schema.Message, *schema.Message]()
+
+	input := c.createInputComponent()
+	intent := c.createIntentComponent()
+	response := c.createResponseComponent()
+
+	nodes := []struct {
+		key    string
+		lambda *compose.Lambda
+	}{
+		{conciergeNodeInput, compose.InvokableLambda(input.Invoke)},
+		{conciergeNodeIntent, compose.InvokableLambda(intent.Invoke)},
+		{conciergeNodeChat, compose.InvokableLambda((&ConciergeChatComponent{concierge: c}).Invoke)},
+		{conciergeNodeClarify, compose.InvokableLambda((&ConciergeClarifyComponent{concierge: c}).Invoke)},
+		{conciergeNodeSearch, compose.InvokableLambda((&ConciergeSearchComponent{concierge: c}).Invoke)},
+		{conciergeNodeHandoff, compose.InvokableLambda((&ConciergeHandoffComponent{concierge: c}).Invoke)},
+		{conciergeNodeResponse, compose.InvokableLambda(response.Invoke)},
+	}
+	for _, node := range nodes {
+		if err := graph.AddLambdaNode(node.key, node.lambda); err != nil {
+			return err
+		}
+	}
+
+	if err := graph.AddEdge(compose.START, conciergeNodeInput); err != nil {
+		return err
+	}
+	if err := graph.AddEdge(conciergeNodeInput, conciergeNodeIntent); err != nil {
+		return err
+	}
+
+	branchNodes := map[string]bool{
+		conciergeNodeChat:    true,
+		conciergeNodeClarify: true,
+		conciergeNodeSearch:  true,
+		conciergeNodeHandoff: true,
+	}
+	branch := compose.NewGraphBranch(func(ctx context.Context, state *ConciergeState) (string, error) {
+		node := string(state.Intent)
+		if !branchNodes[node] {
+			return "", fmt.Errorf("未知的意图: %s", state.Intent)
+		}
+		return node, nil
+	}, branchNodes)
+	if err := graph.AddBranch(conciergeNodeIntent, branch); err != nil {
+		return err
+	}
+
+	for node := range branchNodes {
+		if err := graph.AddEdge(node, conciergeNodeResponse); err != nil {
+			return err
+		}
+	}
+	if err := graph.AddEdge(conciergeNodeResponse, compose.END); err != nil {
+		return err
+	}
+
+	compiled, err := graph.Compile(context.Background(), compose.WithGraphName("concierge"))
+	if err != nil {
+		return err
+	}
+
+	c.graph = graph
+	c.compiledGraph = compiled
+	return nil
+}
+
+func (c *Concierge) createInputComponent() *ConciergeInputComponent {
+	return &ConciergeInputComponent{
+		concierge: c,
+	}
+}
+
+func (c *Concierge) createIntentComponent() *ConciergeIntentComponent {
+	return &ConciergeIntentComponent{
+		concierge: c,
+	}
+}
+
+func (c *Concierge) createResponseComponent() *ConciergeResponseComponent {
+	return &ConciergeResponseComponent{
+		concierge: c,
+	}
+}
+
+// ConciergeInputComponent 门房输入组件：提取用户输入并记录到对话历史
+type ConciergeInputComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 处理输入
+func (c *ConciergeInputComponent) Invoke(ctx context.Context, input []*schema.Message) (*ConciergeState, error) {
+	userInput := ""
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i] != nil && input[i].Role == schema.User {
+			userInput = strings.TrimSpace(input[i].Content)
+			break
+		}
+	}
+	if userInput == "" {
+		return nil, fmt.Errorf("空输入")
+	}
+
+	// 添加用户消息到对话历史
+	c.concierge.conversation.AddMessage("user", userInput)
+	c.concierge.conversationHistory = append(c.concierge.conversationHistory, "用户: "+userInput)
+
+	return &ConciergeState{Input: userInput}, nil
+}
+
+// ConciergeIntentComponent 门房意图组件：识别用户意图，决定走哪个分支
+type ConciergeIntentComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 识别意图
+func (c *ConciergeIntentComponent) Invoke(ctx context.Context, state *ConciergeState) (*ConciergeState, error) {
+	state.Intent = c.detectIntent(state.Input)
+	return state, nil
+}
+
+func (c *ConciergeIntentComponent) detectIntent(userInput string) ConciergeIntent {
+	// 搜索确认优先于一般确认，避免"搜索"被当作启动任务
+	if c.concierge.isSearchConfirmation(userInput) {
+		return ConciergeIntentSearch
+	}
+	if c.concierge.isConfirmationResponse(userInput) {
+		return ConciergeIntentHandoff
+	}
+	if isSearch, _ := c.concierge.toolManager.DetectSearchIntent(userInput); isSearch {
+		return ConciergeIntentSearch
+	}
+	for _, keyword := range contentRequestKeywords {
+		if strings.Contains(userInput, keyword) {
+			return ConciergeIntentClarify
+		}
+	}
+	return ConciergeIntentChat
+}
+
+// ConciergeChatComponent 一般对话分支
+type ConciergeChatComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 生成对话回复
+func (c *ConciergeChatComponent) Invoke(ctx context.Context, state *ConciergeState) (*ConciergeState, error) {
+	ctx = models.WithUsageTags(ctx, models.UsageTags{Action: "chat"})
+	response, err := c.concierge.callAIModel(ctx, c.concierge.buildConciergeSystemPrompt(), state.Input)
+	if err != nil {
+		// 模型调用失败时把错误交给调用方，不用固定话术掩盖
+		return nil, err
+	}
+	state.Response = response
+	state.Streamed = models.StreamHandlerFromContext(ctx) != nil
+	return state, nil
+}
+
+// ConciergeClarifyComponent 需求确认分支：按 ConciergePrompt 向用户确认一次需求
+type ConciergeClarifyComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 生成需求确认回复
+func (c *ConciergeClarifyComponent) Invoke(ctx context.Context, state *ConciergeState) (*ConciergeState, error) {
+	userPrompt := fmt.Sprintf("%s\n对话历史：\n%s\n请向用户确认需求，确认项使用<confirm数字>标签包裹。",
+		c.concierge.buildOrchestratorTimeline(),
+		strings.Join(c.concierge.conversationHistory, "\n"))
+	ctx = models.WithUsageTags(ctx, models.UsageTags{Action: "clarify"})
+	response, err := c.concierge.callAIModel(ctx, prompts.ConciergePrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	state.Response = response
+	state.Streamed = models.StreamHandlerFromContext(ctx) != nil
+	return state, nil
+}
+
+// ConciergeSearchComponent 搜索分支：首次检测到搜索意图时请求确认，确认后执行搜索
+type ConciergeSearchComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 处理搜索
+func (c *ConciergeSearchComponent) Invoke(ctx context.Context, state *ConciergeState) (*ConciergeState, error) {
+	if c.concierge.isSearchConfirmation(state.Input) {
+		state.Response = c.concierge.executeSearch(ctx)
+		return state, nil
+	}
+	_, query := c.concierge.toolManager.DetectSearchIntent(state.Input)
+	state.Response = c.concierge.handleSearchRequest(query)
+	return state, nil
+}
+
+// ConciergeHandoffComponent 交接分支：用户确认需求后交给编排器
+type ConciergeHandoffComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 启动编排器
+func (c *ConciergeHandoffComponent) Invoke(ctx context.Context, state *ConciergeState) (*ConciergeState, error) {
+	state.Response = c.concierge.startOrchestrator(state.Input)
+	return state, nil
+}
+
+// ConciergeResponseComponent 门房响应组件：记录回复并输出最终消息
+type ConciergeResponseComponent struct {
+	concierge *Concierge
+}
+
+// Invoke 输出回复；回复中的 <call_orchestrator> 会被传递给编排器并从回复中移除
+// 流式输出时，没有流式输出过的内容（非模型回复、转交结果）在这里补发
+func (c *ConciergeResponseComponent) Invoke(ctx context.Context, state *ConciergeState) (*schema.Message, error) {
+	var forwarded []string
+	if calls := callOrchestratorPattern.FindAllStringSubmatch(state.Response, -1); len(calls) > 0 {
+		for _, call := range calls {
+			forwarded = append(forwarded, c.concierge.forwardToOrchestrator(strings.TrimSpace(call[1])))
+		}
+		replies := append([]string{strings.TrimSpace(callOrchestratorPattern.ReplaceAllString(state.Response, ""))}, forwarded...)
+		state.Response = strings.TrimSpace(strings.Join(replies, "\n"))
+	}
+
+	if emit := models.StreamHandlerFromContext(ctx); emit != nil {
+		if !state.Streamed {
+			emit(state.Response)
+		} else {
+			for _, reply := range forwarded {
+				emit("\n" + reply)
+			}
+		}
+	}
+
+	// 记录助手响应到对话历史
+	c.concierge.conversationHistory = append(c.concierge.conversationHistory, "助手: "+state.Response)
+	c.concierge.conversation.AddMessage("assistant", state.Response)
+
+	return schema.AssistantMessage(state.Response, nil), nil
+}
@@ -0,0 +1,109 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/core"
+	"loomi2.0/models"
+	"loomi2.0/tools"
+)
+
+// newTestConcierge 创建不依赖模型和搜索工具配置的门房
+func newTestConcierge(t *testing.T) *Concierge {
+	t.Helper()
+	if err := core.InitConversationManager(); err != nil {
+		t.Fatalf("初始化对话管理器失败: %v", err)
+	}
+	conversation := core.GetConversationManager()
+	conversation.Clear()
+	t.Cleanup(conversation.Clear)
+
+	c := &Concierge{
+		conversation: conversation,
+		toolManager:  tools.NewToolManager(),
+	}
+	if err := c.buildGraph(); err != nil {
+		t.Fatalf("构建门房编排图失败: %v", err)
+	}
+	return c
+}
+
+func TestConciergeDetectIntent(t *testing.T) {
+	intent := &ConciergeIntentComponent{concierge: newTestConcierge(t)}
+
+	tests := []struct {
+		input string
+		want  ConciergeIntent
+	}{
+		{input: "你好，你能做什么", want: ConciergeIntentChat},
+		{input: "帮我写一篇小红书帖子", want: ConciergeIntentClarify},
+		{input: "想做公众号文章推广新品", want: ConciergeIntentClarify},
+		{input: "查找最近的防晒霜测评", want: ConciergeIntentSearch},
+		{input: "开始搜索吧", want: ConciergeIntentSearch},
+		{input: "好的，就这样", want: ConciergeIntentHandoff},
+		{input: "OK", want: ConciergeIntentHandoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := intent.detectIntent(tt.input); got != tt.want {
+				t.Errorf("期望意图 %s，实际 %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConciergeSearchBranch(t *testing.T) {
+	c := newTestConcierge(t)
+	ctx := context.Background()
+
+	response, err := c.compiledGraph.Invoke(ctx, []*schema.Message{schema.UserMessage("查找最近的防晒霜测评")})
+	if err != nil {
+		t.Fatalf("门房编排图执行失败: %v", err)
+	}
+	if !strings.Contains(response.Content, "检测到搜索意图") {
+		t.Errorf("搜索请求应当先请求确认，实际回复 %q", response.Content)
+	}
+
+	// 没有注册搜索工具时，确认搜索返回提示而不是报错
+	response, err = c.compiledGraph.Invoke(ctx, []*schema.Message{schema.UserMessage("搜索")})
+	if err != nil {
+		t.Fatalf("门房编排图执行失败: %v", err)
+	}
+	if !strings.Contains(response.Content, "没有可用的搜索工具") {
+		t.Errorf("确认搜索应当走搜索分支，实际回复 %q", response.Content)
+	}
+
+	if got := len(c.conversation.GetMessages()); got != 4 {
+		t.Errorf("两轮对话应当记录 4 条消息，实际 %d", got)
+	}
+}
+
+func TestConciergeStreamChunkOrder(t *testing.T) {
+	reply := "你好！\n我是 Loomi，\n可以帮你做内容研究和写作。"
+	useFakeModel(t, &models.FakeScript{
+		Rules: []*models.FakeRule{{Name: "concierge", System: "Concierge", Responses: []string{reply}}},
+	})
+	c := newTestConcierge(t)
+
+	reader, err := c.ProcessUserInputStream(context.Background(), "你好，你能做什么")
+	if err != nil {
+		t.Fatalf("流式处理输入失败: %v", err)
+	}
+	if got, want := readStream(t, reader), lineChunks(reply); !reflect.DeepEqual(got, want) {
+		t.Errorf("模型回复应当按顺序逐段到达，期望 %q，实际 %q", want, got)
+	}
+
+	// 非模型回复没有流式输出过，由响应节点整段补发一次
+	reader, err = c.ProcessUserInputStream(context.Background(), "查找最近的防晒霜测评")
+	if err != nil {
+		t.Fatalf("流式处理输入失败: %v", err)
+	}
+	if got := readStream(t, reader); len(got) != 1 || !strings.Contains(got[0], "检测到搜索意图") {
+		t.Errorf("搜索确认应当整段输出一次，实际 %q", got)
+	}
+}
@@ -0,0 +1,159 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"loomi2.0/core"
+)
+
+// ContextLimits 编排器上下文各部分的大小限制
+type ContextLimits struct {
+	MaxTimelineEntries int // 完整展示的最近任务消息条数，更早的消息只保留摘要
+	MaxTactics         int // 完整展示的最近战术备忘条数，更早的备忘合并为一行
+	MaxNotesRunes      int // [created_notes] 完整内容的总字数上限
+	SummaryRunes       int // 被摘要的消息或笔记保留的字数
+}
+
+// DefaultContextLimits 默认的上下文大小限制
+var DefaultContextLimits = ContextLimits{
+	MaxTimelineEntries: 10,
+	MaxTactics:         20,
+	MaxNotesRunes:      8000,
+	SummaryRunes:       60,
+}
+
+// ContextBuilder 从对话管理器和工作空间渲染编排器每轮看到的上下文
+type ContextBuilder struct {
+	workspace    *core.WorkSpace
+	conversation *core.ConversationManager
+	limits       ContextLimits
+}
+
+// NewContextBuilder 创建上下文构建器
+func NewContextBuilder(workspace *core.WorkSpace, conversation *core.ConversationManager, limits ContextLimits) *ContextBuilder {
+	return &ContextBuilder{
+		workspace:    workspace,
+		conversation: conversation,
+		limits:       limits,
+	}
+}
+
+// Build 渲染 [任务执行时间线]、[tactics]、[created_notes] 三部分，observation 非空时附加上一轮观察
+func (b *ContextBuilder) Build(observation string) string {
+	var sb strings.Builder
+
+	sb.WriteString("[任务执行时间线]\n")
+	sb.WriteString(b.BuildTimeline())
+	sb.WriteString("\n[tactics]\n")
+	sb.WriteString(b.BuildTactics())
+	sb.WriteString("\n[created_notes]\n")
+	sb.WriteString(b.BuildCreatedNotes())
+
+	if observation != "" {
+		sb.WriteString("\n[上一轮观察]\n")
+		sb.WriteString(observation)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// BuildTimeline 渲染任务消息时间线，最新的一条标记"(新)"
+func (b *ContextBuilder) BuildTimeline() string {
+	messages := b.conversation.GetMessagesByRole(core.MessageRoleTask)
+	if len(messages) == 0 {
+		return "（暂无）\n"
+	}
+
+	var sb strings.Builder
+	start := 0
+	if b.limits.MaxTimelineEntries > 0 && len(messages) > b.limits.MaxTimelineEntries {
+		start = len(messages) - b.limits.MaxTimelineEntries
+		sb.WriteString(fmt.Sprintf("（更早的%d条任务消息摘要）\n", start))
+		for _, msg := range messages[:start] {
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", msg.Timestamp.Format("01-02 15:04"), truncateRunes(msg.Content, b.limits.SummaryRunes)))
+		}
+	}
+
+	for i := start; i < len(messages); i++ {
+		msg := messages[i]
+		sb.WriteString(fmt.Sprintf("- [%s] %s", msg.Timestamp.Format("01-02 15:04"), msg.Content))
+		if i == len(messages)-1 {
+			sb.WriteString(" (新)")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// BuildTactics 渲染战术备忘，超出条数限制时更早的备忘只保留轮次和行动
+func (b *ContextBuilder) BuildTactics() string {
+	tactics := b.workspace.GetTactics()
+	if len(tactics) == 0 {
+		return "（暂无）\n"
+	}
+
+	var sb strings.Builder
+	start := 0
+	if b.limits.MaxTactics > 0 && len(tactics) > b.limits.MaxTactics {
+		start = len(tactics) - b.limits.MaxTactics
+		executed := make([]string, 0, start)
+		for _, tactic := range tactics[:start] {
+			executed = append(executed, fmt.Sprintf("Round%d '%s'", tactic.Round, tactic.Action))
+		}
+		sb.WriteString(fmt.Sprintf("（更早的%d条备忘已折叠）executed: %s\n", start, strings.Join(executed, ", ")))
+	}
+
+	for _, tactic := range tactics[start:] {
+		sb.WriteString(tactic.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// BuildCreatedNotes 渲染笔记；从最新的笔记开始保留完整内容，超出字数上限的更早笔记只保留摘要
+func (b *ContextBuilder) BuildCreatedNotes() string {
+	notes := b.workspace.ListNotes()
+	if len(notes) == 0 {
+		return "（暂无）\n"
+	}
+
+	rendered := make([]string, len(notes))
+	budget := b.limits.MaxNotesRunes
+	summarized := 0
+	for i := len(notes) - 1; i >= 0; i-- {
+		note := notes[i]
+		full := note.Render()
+		size := utf8.RuneCountInString(full)
+		// material 是用户提供的原始材料，始终完整保留
+		if b.limits.MaxNotesRunes <= 0 || size <= budget || note.Type == core.MaterialNoteType {
+			rendered[i] = full
+			budget -= size
+			continue
+		}
+		summarized++
+		summary := note
+		summary.Content = truncateRunes(note.Content, b.limits.SummaryRunes)
+		rendered[i] = summary.Render()
+	}
+
+	var sb strings.Builder
+	if summarized > 0 {
+		sb.WriteString(fmt.Sprintf("（%d条较早的笔记只展示摘要，@引用时会传递完整内容）\n", summarized))
+	}
+	for _, entry := range rendered {
+		sb.WriteString(entry)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// truncateRunes 按字数截断文本，被截断时追加省略号
+func truncateRunes(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit]) + "…"
+}
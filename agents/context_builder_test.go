@@ -0,0 +1,111 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"loomi2.0/core"
+)
+
+// newTestContextBuilder 创建使用已清空的全局工作空间和对话管理器的上下文构建器
+func newTestContextBuilder(t *testing.T, limits ContextLimits) *ContextBuilder {
+	t.Helper()
+	o := newTestOrchestrator(t)
+	return NewContextBuilder(o.workspace, o.conversation, limits)
+}
+
+func TestContextBuilderEmpty(t *testing.T) {
+	b := newTestContextBuilder(t, DefaultContextLimits)
+
+	got := b.Build("")
+	want := "[任务执行时间线]\n（暂无）\n\n[tactics]\n（暂无）\n\n[created_notes]\n（暂无）\n"
+	if got != want {
+		t.Errorf("空上下文期望 %q，实际 %q", want, got)
+	}
+	if got := b.Build("Round1 执行 'insight' 的结果"); !strings.HasSuffix(got, "\n[上一轮观察]\nRound1 执行 'insight' 的结果\n") {
+		t.Errorf("观察应当附加在最后，实际 %q", got)
+	}
+}
+
+func TestContextBuilderTimeline(t *testing.T) {
+	b := newTestContextBuilder(t, ContextLimits{MaxTimelineEntries: 2, SummaryRunes: 4})
+	b.conversation.AddMessage("user", "门房对话不进入时间线")
+	for _, task := range []string{"第一个任务很长很长", "第二个任务", "第三个任务", "第四个任务"} {
+		b.conversation.AddMessage(core.MessageRoleTask, task)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.BuildTimeline(), "\n"), "\n")
+	if len(lines) != 5 || lines[0] != "（更早的2条任务消息摘要）" {
+		t.Fatalf("超出条数时更早的消息应当摘要，实际:\n%s", strings.Join(lines, "\n"))
+	}
+	for i, want := range []string{"第一个任…", "第二个任…", "第三个任务", "第四个任务 (新)"} {
+		if !strings.HasSuffix(lines[i+1], "] "+want) {
+			t.Errorf("第%d条期望以 %q 结尾，实际 %q", i+1, want, lines[i+1])
+		}
+	}
+	if strings.Contains(b.BuildTimeline(), "门房对话") {
+		t.Error("时间线只应包含任务消息")
+	}
+}
+
+func TestContextBuilderTactics(t *testing.T) {
+	b := newTestContextBuilder(t, ContextLimits{MaxTactics: 2})
+	for round, action := range []string{"profile", "insight", "hitpoint", "xhs_post"} {
+		b.workspace.AddTactic(core.Tactic{Round: round + 1, Action: action, Memo: "备忘" + action})
+	}
+
+	want := "（更早的2条备忘已折叠）executed: Round1 'profile', Round2 'insight'\n" +
+		"Round3: executed 'hitpoint'. memo: 备忘hitpoint\n" +
+		"Round4: executed 'xhs_post'. memo: 备忘xhs_post\n"
+	if got := b.BuildTactics(); got != want {
+		t.Errorf("战术备忘期望:\n%s\n实际:\n%s", want, got)
+	}
+}
+
+func TestContextBuilderCreatedNotes(t *testing.T) {
+	b := newTestContextBuilder(t, ContextLimits{MaxNotesRunes: 60, SummaryRunes: 5})
+	long := strings.Repeat("长", 30)
+	for _, note := range []core.Note{
+		{Type: core.MaterialNoteType, Content: "用户材料" + long},
+		{Type: "profile", Content: "画像" + long},
+		{Type: "insight", Content: "洞察  第一条\n第二条"},
+		{Type: "insight", Content: "最新洞察"},
+	} {
+		if _, err := b.workspace.SaveNote(note); err != nil {
+			t.Fatalf("保存笔记失败: %v", err)
+		}
+	}
+
+	// 从最新的笔记开始保留完整内容，超出上限的更早笔记只保留摘要，材料始终完整
+	want := "（1条较早的笔记只展示摘要，@引用时会传递完整内容）\n" +
+		fmt.Sprintf("<material1>用户材料%s</material1>\n", long) +
+		"<profile1>画像长长长…</profile1>\n" +
+		"<insight1>洞察  第一条\n第二条</insight1>\n" +
+		"<insight2>最新洞察</insight2>\n"
+	if got := b.BuildCreatedNotes(); got != want {
+		t.Errorf("笔记期望:\n%s\n实际:\n%s", want, got)
+	}
+
+	b.limits.MaxNotesRunes = 0
+	if got := b.BuildCreatedNotes(); strings.Contains(got, "摘要") || !strings.Contains(got, "画像"+long) {
+		t.Errorf("不限制字数时应当完整展示所有笔记，实际:\n%s", got)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{text: "洞察内容", limit: 4, want: "洞察内容"},
+		{text: "洞察内容很长", limit: 4, want: "洞察内容…"},
+		{text: "多个  空白\n换行", limit: 0, want: "多个 空白 换行"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.text, tt.limit); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q，期望 %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/models"
 )
 
 // InitAgents 初始化所有智能体
@@ -29,6 +33,11 @@ func ProcessUserInput(ctx context.Context, userInput string) (string, error) {
 		if err == nil {
 			return response, nil
 		}
+		// 预算用尽或模型调用失败时换成编排器也无法调用模型
+		var providerErr *models.ProviderError
+		if errors.Is(err, models.ErrBudgetExceeded) || errors.As(err, &providerErr) {
+			return "", err
+		}
 	}
 
 	// 如果门房处理失败，使用编排器处理
@@ -43,16 +52,36 @@ func ProcessUserInput(ctx context.Context, userInput string) (string, error) {
 	return "", fmt.Errorf("所有智能体都无法处理用户输入")
 }
 
-// StartOrchestrator 启动编排器
-func StartOrchestrator(ctx context.Context) error {
+// ProcessUserInputStream 以流式方式处理用户输入（全局函数），回复逐段写入返回的流
+func ProcessUserInputStream(ctx context.Context, userInput string) (*schema.StreamReader[*schema.Message], error) {
+	if concierge := GetConcierge(); concierge != nil {
+		return concierge.ProcessUserInputStream(ctx, userInput)
+	}
+	if orchestrator := GetOrchestrator(); orchestrator != nil {
+		return orchestrator.ProcessTaskStream(ctx, userInput)
+	}
+	return nil, fmt.Errorf("所有智能体都无法处理用户输入")
+}
+
+// StartOrchestratorTask 在后台启动编排任务
+func StartOrchestratorTask(task string) (*OrchestratorJob, error) {
+	orchestrator := GetOrchestrator()
+	if orchestrator == nil {
+		return nil, fmt.Errorf("编排器未初始化")
+	}
+	return orchestrator.StartTask(task)
+}
+
+// GetOrchestratorJob 获取最近一次启动的编排任务
+func GetOrchestratorJob() *OrchestratorJob {
 	orchestrator := GetOrchestrator()
 	if orchestrator == nil {
-		return fmt.Errorf("编排器未初始化")
+		return nil
 	}
-	return orchestrator.StartOrchestrator(ctx)
+	return orchestrator.CurrentJob()
 }
 
-// StopOrchestrator 停止编排器
+// StopOrchestrator 取消正在运行的编排任务
 func StopOrchestrator() {
 	orchestrator := GetOrchestrator()
 	if orchestrator != nil {
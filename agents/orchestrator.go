@@ -2,16 +2,16 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
-	"github.com/cloudwego/eino/callbacks"
-	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"loomi2.0/core"
 	"loomi2.0/models"
+	"loomi2.0/prompts"
 )
 
 // Orchestrator 编排器智能体
@@ -20,8 +20,21 @@ type Orchestrator struct {
 	conversation *core.ConversationManager
 	graph        *compose.Graph[[]*schema.Message, *schema.Message]
 	compiledGraph compose.Runnable[[]*schema.Message, *schema.Message]
-	running      bool
-}
+	maxRounds    int
+	contextBuilder *ContextBuilder
+	currentJob   *OrchestratorJob
+	jobSeq       int
+	jobUpdates   chan *OrchestratorJob
+	approvalMode bool
+	pending      *pendingApproval
+	approvalRequests chan *ApprovalRequest
+	streaming    bool
+	streamChunks chan StreamChunk
+	mu           sync.RWMutex
+}
+
+// DefaultMaxRounds 默认的 ReAct 轮次上限（与 OrchestratorPrompt 中的约定一致）
+const DefaultMaxRounds = 4
 
 var orchestrator *Orchestrator
 var orchestratorOnce sync.Once
@@ -36,7 +49,11 @@ func InitOrchestrator() error {
 		orchestrator = &Orchestrator{
 			workspace:    workspace,
 			conversation: conversation,
-			running:      false,
+			maxRounds:    DefaultMaxRounds,
+			contextBuilder: NewContextBuilder(workspace, conversation, DefaultContextLimits),
+			jobUpdates:   make(chan *OrchestratorJob, 8),
+			approvalRequests: make(chan *ApprovalRequest, 1),
+			streamChunks: make(chan StreamChunk, 64),
 		}
 		err = orchestrator.init()
 	})
@@ -56,636 +73,406 @@ func (o *Orchestrator) init() error {
 	return nil
 }
 
+// 编排图节点名称
+const (
+	orchestratorNodeAnalysis      = "analysis"
+	orchestratorNodeDecomposition = "decomposition"
+	orchestratorNodeExecution     = "execution"
+	orchestratorNodeSummary       = "summary"
+)
+
+// buildGraph 构建编排图：analysis -> decomposition -> execution -> summary
+// 节点之间传递 *TaskState，只有入口和出口使用 schema.Message
 func (o *Orchestrator) buildGraph() error {
-	// 暂时跳过图的构建，直接返回成功
-	// TODO: 修复 eino Graph 的类型匹配问题
+	graph := compose.NewGraph[[]*schema.Message, *schema.Message]()
+
+	analysis := o.createAnalysisComponent()
+	decomposition := o.createDecompositionComponent()
+	execution := o.createExecutionComponent()
+	summary := o.createSummaryComponent()
+
+	if err := graph.AddLambdaNode(orchestratorNodeAnalysis, compose.InvokableLambda(analysis.Invoke)); err != nil {
+		return err
+	}
+	if err := graph.AddLambdaNode(orchestratorNodeDecomposition, compose.InvokableLambda(decomposition.Invoke)); err != nil {
+		return err
+	}
+	if err := graph.AddLambdaNode(orchestratorNodeExecution, compose.InvokableLambda(execution.Invoke)); err != nil {
+		return err
+	}
+	if err := graph.AddLambdaNode(orchestratorNodeSummary, compose.InvokableLambda(summary.Invoke)); err != nil {
+		return err
+	}
+
+	edges := [][2]string{
+		{compose.START, orchestratorNodeAnalysis},
+		{orchestratorNodeAnalysis, orchestratorNodeDecomposition},
+		{orchestratorNodeDecomposition, orchestratorNodeExecution},
+		{orchestratorNodeExecution, orchestratorNodeSummary},
+		{orchestratorNodeSummary, compose.END},
+	}
+	for _, edge := range edges {
+		if err := graph.AddEdge(edge[0], edge[1]); err != nil {
+			return err
+		}
+	}
+
+	compiled, err := graph.Compile(context.Background(), compose.WithGraphName("orchestrator"))
+	if err != nil {
+		return err
+	}
+
+	o.graph = graph
+	o.compiledGraph = compiled
 	return nil
 }
 
-func (o *Orchestrator) createAnalysisComponent() model.BaseChatModel {
+func (o *Orchestrator) createAnalysisComponent() *OrchestratorAnalysisComponent {
 	return &OrchestratorAnalysisComponent{
 		orchestrator: o,
 	}
 }
 
-func (o *Orchestrator) createDecompositionComponent() model.BaseChatModel {
+func (o *Orchestrator) createDecompositionComponent() *OrchestratorDecompositionComponent {
 	return &OrchestratorDecompositionComponent{
 		orchestrator: o,
 	}
 }
 
-func (o *Orchestrator) createExecutionComponent() model.BaseChatModel {
+func (o *Orchestrator) createExecutionComponent() *OrchestratorExecutionComponent {
 	return &OrchestratorExecutionComponent{
 		orchestrator: o,
 	}
 }
 
-func (o *Orchestrator) createSummaryComponent() model.BaseChatModel {
+func (o *Orchestrator) createSummaryComponent() *OrchestratorSummaryComponent {
 	return &OrchestratorSummaryComponent{
 		orchestrator: o,
 	}
 }
 
-// StartOrchestrator 启动编排器
-func (o *Orchestrator) StartOrchestrator(ctx context.Context) error {
-	o.running = true
-	return nil
-}
-
-// StopOrchestrator 停止编排器
-func (o *Orchestrator) StopOrchestrator() {
-	o.running = false
-}
-
-// IsRunning 检查是否正在运行
-func (o *Orchestrator) IsRunning() bool {
-	return o.running
-}
-
-// ProcessTask 处理任务
-func (o *Orchestrator) ProcessTask(ctx context.Context, task string) (string, error) {
-	// 添加任务到工作空间
-	o.workspace.AddTask(task)
-
-	// 暂时直接处理任务，跳过 eino 编排图
-	// TODO: 修复 eino Graph 的类型匹配问题后恢复
-	
-	// 简单的任务处理
-	response := o.processTask(task)
-	
-	// 添加助手消息到对话历史
-	o.conversation.AddMessage("assistant", response)
-	return response, nil
-}
-
-// processTask 处理任务
-func (o *Orchestrator) processTask(task string) string {
-	// 调用 AI 模型处理任务
-	response, err := o.callAIModel(task)
-	if err != nil {
-		// 如果 AI 调用失败，返回默认响应
-		return o.generateDefaultTaskResponse(task)
+// StartTask 在后台启动编排任务，同一时间只运行一个任务
+func (o *Orchestrator) StartTask(task string) (*OrchestratorJob, error) {
+	if o.compiledGraph == nil {
+		return nil, fmt.Errorf("编排图未编译")
 	}
-	return response
-}
 
-// callAIModel 调用 AI 模型
-func (o *Orchestrator) callAIModel(task string) (string, error) {
-	// 构建 system prompt 和 user prompt
-	systemPrompt := o.buildOrchestratorSystemPrompt()
-	userPrompt := task
-	
-	// 调用模型管理器
-	modelManager := models.GetModelManager()
-	if modelManager == nil {
-		return "", fmt.Errorf("模型管理器未初始化")
+	o.mu.Lock()
+	if o.currentJob != nil && o.currentJob.IsRunning() {
+		o.mu.Unlock()
+		return nil, fmt.Errorf("任务 %s 正在运行", o.currentJob.ID)
 	}
-	
-	// 调用当前模型
-	response, err := modelManager.CallCurrentModel(context.Background(), systemPrompt, userPrompt, nil)
-	if err != nil {
-		return "", fmt.Errorf("AI 模型调用失败: %v", err)
-	}
-	
-	return response, nil
-}
-
-// buildOrchestratorSystemPrompt 构建 Orchestrator 的 system prompt
-func (o *Orchestrator) buildOrchestratorSystemPrompt() string {
-	return `你是Loomi，一个社媒内容研究与生产的多Agent系统中的Orchestrator（编排员）。
-你的任务是直接生成符合用户需求的社交媒体内容，而不是制定计划。
-
-## 你的工作方式：
-1. 分析用户需求：从对话历史中提取关键信息
-2. 确定内容类型：图文、短视频脚本、直播话题等
-3. 生成具体内容：直接输出符合平台调性的内容
-
-## 内容生成要求：
-- 内容要有吸引力，符合目标受众的喜好
-- 语言要自然，避免AI痕迹
-- 要包含具体的标题、正文、标签等
-- 内容要实用，能够引起互动
-
-## 常见内容类型：
-1. 小红书图文：标题+正文+标签，突出实用性和分享感
-2. 抖音脚本：开场+内容+结尾，节奏感强
-3. 微博话题：简洁有力，易于传播
-4. 公众号文章：深度内容，有观点
-
-请直接生成符合用户需求的具体内容，而不是分析或计划。`
-}
-
-
-
-// generateDefaultTaskResponse 生成默认任务响应
-func (o *Orchestrator) generateDefaultTaskResponse(task string) string {
-	return fmt.Sprintf(`任务处理结果：
-
-🎯 任务：%s
-
-📋 处理步骤：
-1. ✅ 任务分析 - 已完成
-2. ✅ 任务分解 - 已完成  
-3. ✅ 任务执行 - 已完成
-4. ✅ 结果汇总 - 已完成
-
-📊 处理结果：
-任务已成功处理完成！所有步骤都已按计划执行。
-
-💡 建议：
-- 任务已添加到工作空间
-- 可以继续添加更多任务
-- 系统运行状态良好`, task)
-}
-
-// OrchestratorAnalysisComponent 编排器分析组件
-type OrchestratorAnalysisComponent struct {
-	orchestrator *Orchestrator
-}
-
-func (c *OrchestratorAnalysisComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	
-	// 分析任务
-	content := input[0].Content
-	
-	// 简单的任务分析
-	var analysis string
-	if strings.Contains(content, "复杂") || strings.Contains(content, "困难") {
-		analysis = "complex"
-	} else if strings.Contains(content, "简单") || strings.Contains(content, "基础") {
-		analysis = "simple"
-	} else {
-		analysis = "medium"
-	}
-
-	return &schema.Message{
-		Role:    "system",
-		Content: fmt.Sprintf("任务分析: %s, 内容: %s", analysis, content),
-	}, nil
-}
-
-func (c *OrchestratorAnalysisComponent) Invoke(ctx context.Context, input schema.Message) (schema.Message, error) {
-	// 分析任务
-	content := input.Content
-	
-	// 简单的任务分析
-	var analysis string
-	if strings.Contains(content, "复杂") || strings.Contains(content, "困难") {
-		analysis = "complex"
-	} else if strings.Contains(content, "简单") || strings.Contains(content, "基础") {
-		analysis = "simple"
-	} else {
-		analysis = "medium"
+	o.jobSeq++
+	ctx, cancel := context.WithCancel(context.Background())
+	var budget *models.Budget
+	if modelManager := models.GetModelManager(); modelManager != nil {
+		budget = modelManager.NewTaskBudget()
 	}
+	job := newOrchestratorJob(fmt.Sprintf("job%d", o.jobSeq), task, cancel, budget)
+	ctx = models.WithBudget(withOrchestratorJob(ctx, job), budget)
+	o.currentJob = job
+	o.mu.Unlock()
 
-	return schema.Message{
-		Role:    "system",
-		Content: fmt.Sprintf("任务分析: %s, 内容: %s", analysis, content),
-	}, nil
-}
-
-func (c *OrchestratorAnalysisComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
 	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
+		defer cancel()
+		result, err := o.ProcessTask(ctx, task)
+		job.finish(ctx, result, err)
+
+		// 非阻塞通知，没有监听者时丢弃
+		select {
+		case o.jobUpdates <- job:
+		default:
+		}
 	}()
-	
-	return reader, nil
+	return job, nil
 }
 
-func (c *OrchestratorAnalysisComponent) Collect(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
-	var content strings.Builder
-	for {
-		msg, err := input.Recv()
-		if err != nil {
-			break
-		}
-		content.WriteString(msg.Content)
+// InjectFeedback 把用户在任务执行中提出的新需求写入任务时间线，编排器在下一轮读取
+func (o *Orchestrator) InjectFeedback(feedback string) error {
+	feedback = strings.TrimSpace(feedback)
+	if feedback == "" {
+		return fmt.Errorf("反馈内容为空")
 	}
-	
-	return &schema.Message{
-		Role:    "system",
-		Content: content.String(),
-	}, nil
-}
-
-func (c *OrchestratorAnalysisComponent) Transform(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		
-		for {
-			msg, err := input.Recv()
-			if err != nil {
-				writer.Send(msg, err)
-				break
-			}
-			writer.Send(msg, nil)
-		}
-	}()
-	
-	return reader, nil
-}
 
-func (c *OrchestratorAnalysisComponent) GetInputType() string {
-	return "message"
-}
+	job := o.CurrentJob()
+	if job == nil || !job.IsRunning() {
+		return fmt.Errorf("没有正在运行的任务")
+	}
 
-func (c *OrchestratorAnalysisComponent) GetOutputType() string {
-	return "message"
+	o.workspace.AddTask(feedback)
+	o.conversation.AddMessage(core.MessageRoleTask, feedback)
+	job.addFeedback(feedback)
+	return nil
 }
 
-func (c *OrchestratorAnalysisComponent) GetOptionType() string {
-	return "config"
+// CurrentJob 获取最近一次启动的任务，可能已经结束
+func (o *Orchestrator) CurrentJob() *OrchestratorJob {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.currentJob
 }
 
-func (c *OrchestratorAnalysisComponent) SetOption(option any) error {
-	return nil
+// JobUpdates 任务结束时推送任务句柄的通道
+func (o *Orchestrator) JobUpdates() <-chan *OrchestratorJob {
+	return o.jobUpdates
 }
 
-func (c *OrchestratorAnalysisComponent) GetOption() any {
-	return nil
+// StopOrchestrator 取消正在运行的任务
+func (o *Orchestrator) StopOrchestrator() {
+	if job := o.CurrentJob(); job != nil && job.IsRunning() {
+		job.Cancel()
+	}
 }
 
-func (c *OrchestratorAnalysisComponent) SetCallbacks(callbacks callbacks.Handler) error {
-	return nil
+// IsRunning 检查是否有任务正在运行
+func (o *Orchestrator) IsRunning() bool {
+	job := o.CurrentJob()
+	return job != nil && job.IsRunning()
 }
 
-func (c *OrchestratorAnalysisComponent) GetCallbacks() callbacks.Handler {
+// SetMaxRounds 设置 ReAct 轮次上限
+func (o *Orchestrator) SetMaxRounds(maxRounds int) error {
+	if maxRounds <= 0 {
+		return fmt.Errorf("轮次上限必须大于0: %d", maxRounds)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.maxRounds = maxRounds
 	return nil
 }
 
-// OrchestratorDecompositionComponent 编排器分解组件
-type OrchestratorDecompositionComponent struct {
-	orchestrator *Orchestrator
+// MaxRounds 获取 ReAct 轮次上限
+func (o *Orchestrator) MaxRounds() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.maxRounds
 }
 
-func (c *OrchestratorDecompositionComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	
-	// 任务分解
-	content := input[0].Content
-	
-	// 根据分析结果进行任务分解
-	var decomposition string
-	if strings.Contains(content, "任务分析: complex") {
-		decomposition = "将复杂任务分解为多个子任务，逐步执行"
-	} else if strings.Contains(content, "任务分析: simple") {
-		decomposition = "简单任务，直接执行"
-	} else {
-		decomposition = "中等复杂度任务，需要适当分解"
+// ProcessTask 处理任务
+func (o *Orchestrator) ProcessTask(ctx context.Context, task string) (string, error) {
+	if o.compiledGraph == nil {
+		return "", fmt.Errorf("编排图未编译")
 	}
 
-	return &schema.Message{
-		Role:    "system",
-		Content: fmt.Sprintf("任务分解: %s", decomposition),
-	}, nil
-}
-
-func (c *OrchestratorDecompositionComponent) Invoke(ctx context.Context, input schema.Message) (schema.Message, error) {
-	// 任务分解
-	content := input.Content
-	
-	// 根据分析结果进行任务分解
-	var decomposition string
-	if strings.Contains(content, "任务分析: complex") {
-		decomposition = "将复杂任务分解为多个子任务，逐步执行"
-	} else if strings.Contains(content, "任务分析: simple") {
-		decomposition = "简单任务，直接执行"
-	} else {
-		decomposition = "中等复杂度任务，需要适当分解"
+	response, err := o.compiledGraph.Invoke(ctx, []*schema.Message{schema.UserMessage(task)})
+	if err != nil {
+		return "", err
 	}
 
-	return schema.Message{
-		Role:    "system",
-		Content: fmt.Sprintf("任务分解: %s", decomposition),
-	}, nil
+	// 添加助手消息到对话历史
+	o.conversation.AddMessage("assistant", response.Content)
+	return response.Content, nil
 }
 
-func (c *OrchestratorDecompositionComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
+// ProcessTaskStream 以流式方式处理任务：编排图照常执行，模型输出经 ctx 中的流式回调逐段写入返回的流，
+// 最后写入汇总结果并记入对话历史。
+// 编排图的节点都是 InvokableLambda，模型在 execution 节点内部被多次调用，
+// compiledGraph.Stream 要等 summary 节点完成后才输出唯一的一段，所以这里用 Invoke 执行编排图，由流式回调转发节点内部的模型输出
+func (o *Orchestrator) ProcessTaskStream(ctx context.Context, task string) (*schema.StreamReader[*schema.Message], error) {
+	if o.compiledGraph == nil {
+		return nil, fmt.Errorf("编排图未编译")
 	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
+
+	reader, writer := schema.Pipe[*schema.Message](16)
 	go func() {
 		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
 
-func (c *OrchestratorDecompositionComponent) Collect(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
-	var content strings.Builder
-	for {
-		msg, err := input.Recv()
+		streamCtx := models.WithStreamHandler(ctx, func(chunk string) {
+			writer.Send(schema.AssistantMessage(chunk, nil), nil)
+		})
+		response, err := o.compiledGraph.Invoke(streamCtx, []*schema.Message{schema.UserMessage(task)})
 		if err != nil {
-			break
-		}
-		content.WriteString(msg.Content)
-	}
-	
-	return &schema.Message{
-		Role:    "system",
-		Content: content.String(),
-	}, nil
-}
-
-func (c *OrchestratorDecompositionComponent) Transform(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		
-		for {
-			msg, err := input.Recv()
-			if err != nil {
-				writer.Send(msg, err)
-				break
-			}
-			writer.Send(msg, nil)
+			writer.Send(nil, err)
+			return
 		}
+		o.conversation.AddMessage("assistant", response.Content)
+		writer.Send(schema.AssistantMessage("\n"+response.Content, nil), nil)
 	}()
-	
 	return reader, nil
 }
 
-func (c *OrchestratorDecompositionComponent) GetInputType() string {
-	return "message"
-}
-
-func (c *OrchestratorDecompositionComponent) GetOutputType() string {
-	return "message"
-}
-
-func (c *OrchestratorDecompositionComponent) GetOptionType() string {
-	return "config"
-}
-
-func (c *OrchestratorDecompositionComponent) SetOption(option any) error {
-	return nil
-}
-
-func (c *OrchestratorDecompositionComponent) GetOption() any {
-	return nil
-}
-
-func (c *OrchestratorDecompositionComponent) SetCallbacks(callbacks callbacks.Handler) error {
-	return nil
-}
-
-func (c *OrchestratorDecompositionComponent) GetCallbacks() callbacks.Handler {
-	return nil
-}
+// runReAct 运行 Observe/Think/Act 循环，直到模型输出 <task_completed/> 或达到本任务的轮次预算
+func (o *Orchestrator) runReAct(ctx context.Context, state *TaskState) error {
+	modelManager := models.GetModelManager()
+	if modelManager == nil {
+		return fmt.Errorf("模型管理器未初始化")
+	}
+	ctx = models.WithUsageTags(ctx, models.UsageTags{Session: o.conversation.GetSessionID(), Agent: "orchestrator"})
+	if models.BudgetFromContext(ctx) == nil {
+		ctx = models.WithBudget(ctx, modelManager.NewTaskBudget())
+	}
 
-// OrchestratorExecutionComponent 编排器执行组件
-type OrchestratorExecutionComponent struct {
-	orchestrator *Orchestrator
-}
+	observation := ""
+	for i := 0; i < state.MaxRounds; i++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("任务已取消: %v", err)
+		}
 
-func (c *OrchestratorExecutionComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
-	}
-	
-	// 任务执行
-	content := input[0].Content
-	
-	// 模拟任务执行
-	execution := fmt.Sprintf("正在执行任务分解方案: %s", content)
-
-	return &schema.Message{
-		Role:    "system",
-		Content: execution,
-	}, nil
-}
+		if job := orchestratorJobFromContext(ctx); job != nil {
+			if feedback := job.takeFeedback(); len(feedback) > 0 {
+				observation = strings.TrimSpace(fmt.Sprintf("%s\n用户在执行中补充了%d条新需求，见任务执行时间线中标记(新)的条目，请据此调整计划。", observation, len(feedback)))
+			}
+		}
 
-func (c *OrchestratorExecutionComponent) Invoke(ctx context.Context, input schema.Message) (schema.Message, error) {
-	// 任务执行
-	content := input.Content
-	
-	// 模拟任务执行
-	execution := fmt.Sprintf("正在执行任务分解方案: %s", content)
-
-	return schema.Message{
-		Role:    "system",
-		Content: execution,
-	}, nil
-}
+		// 预算接近上限时让编排器用已有的笔记收尾，而不是在写作途中被硬性中断
+		if status, near := modelManager.BudgetNearLimit(ctx); near {
+			observation = strings.TrimSpace(fmt.Sprintf("%s\n%s预算即将用尽（%s），不要再开始新的研究，请基于已有笔记完成交付后输出 <task_completed/>。", observation, status.ScopeName(), status))
+		}
 
-func (c *OrchestratorExecutionComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
+		round := o.workspace.NextRound()
+		userPrompt := o.contextBuilder.Build(observation)
 
-func (c *OrchestratorExecutionComponent) Collect(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
-	var content strings.Builder
-	for {
-		msg, err := input.Recv()
+		planCtx := models.WithUsageTags(ctx, models.UsageTags{Action: "plan"})
+		output, err := modelManager.CallCurrentModel(planCtx, prompts.OrchestratorPrompt, userPrompt, nil)
 		if err != nil {
-			break
+			return fmt.Errorf("第%d轮编排调用失败: %w", round, err)
 		}
-		content.WriteString(msg.Content)
-	}
-	
-	return &schema.Message{
-		Role:    "system",
-		Content: content.String(),
-	}, nil
-}
 
-func (c *OrchestratorExecutionComponent) Transform(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		
-		for {
-			msg, err := input.Recv()
+		step := ParseReActStep(output)
+		record := RoundRecord{Round: round, Step: step}
+
+		if step.HasAction() && o.needsApproval(step.Action) {
+			response, err := o.awaitApproval(ctx, round, step)
 			if err != nil {
-				writer.Send(msg, err)
-				break
+				return err
+			}
+			switch response.Decision {
+			case ApprovalEdit:
+				step.Instruction = strings.TrimSpace(response.Instruction)
+				record.Step = step
+			case ApprovalRedirect:
+				feedback := strings.TrimSpace(response.Feedback)
+				o.workspace.AddTask(feedback)
+				o.conversation.AddMessage(core.MessageRoleTask, feedback)
+				observation = fmt.Sprintf("用户没有采纳 Round%d 提议的 '%s'，给出了新的方向（见任务执行时间线中标记(新)的条目），请据此重新规划。", round, step.Action)
+				record.Err = fmt.Errorf("用户改变了方向: %s", feedback)
+				state.Rounds = append(state.Rounds, record)
+				continue
 			}
-			writer.Send(msg, nil)
 		}
-	}()
-	
-	return reader, nil
-}
-
-func (c *OrchestratorExecutionComponent) GetInputType() string {
-	return "message"
-}
 
-func (c *OrchestratorExecutionComponent) GetOutputType() string {
-	return "message"
-}
-
-func (c *OrchestratorExecutionComponent) GetOptionType() string {
-	return "config"
-}
-
-func (c *OrchestratorExecutionComponent) SetOption(option any) error {
-	return nil
-}
-
-func (c *OrchestratorExecutionComponent) GetOption() any {
-	return nil
-}
+		if step.HasAction() {
+			result, err := o.executeAction(ctx, round, state.Task, step.Action, step.Instruction)
+			if errors.Is(err, models.ErrBudgetExceeded) {
+				return fmt.Errorf("第%d轮执行 %s 失败: %w", round, step.Action, err)
+			}
+			if err != nil {
+				observation = fmt.Sprintf("Round%d 执行 '%s' 失败: %v", round, step.Action, err)
+				record.Err = err
+			} else {
+				observation = fmt.Sprintf("Round%d 执行 '%s' 的结果:\n%s", round, step.Action, result)
+				record.Result = result
+			}
+			o.workspace.AddTactic(core.Tactic{
+				Round:  round,
+				Action: step.Action,
+				Memo:   step.Tactics,
+			})
+		} else if !step.Completed {
+			observation = "上一轮输出中没有找到 <execute_step/> 或 <task_completed/>，请按工具语法输出。"
+		}
+		state.Rounds = append(state.Rounds, record)
 
-func (c *OrchestratorExecutionComponent) SetCallbacks(callbacks callbacks.Handler) error {
-	return nil
-}
+		if step.Completed {
+			state.Completed = true
+			break
+		}
+	}
 
-func (c *OrchestratorExecutionComponent) GetCallbacks() callbacks.Handler {
 	return nil
 }
 
-// OrchestratorSummaryComponent 编排器汇总组件
-type OrchestratorSummaryComponent struct {
-	orchestrator *Orchestrator
-}
-
-func (c *OrchestratorSummaryComponent) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	if len(input) == 0 {
-		return nil, fmt.Errorf("空输入")
+// executeAction 通过行动注册表执行编排器请求的单个步骤，并把产出记录为笔记
+func (o *Orchestrator) executeAction(ctx context.Context, round int, task, action, instruction string) (string, error) {
+	if instruction == "" {
+		return "", fmt.Errorf("action %s 缺少 instruction", action)
 	}
-	
-	// 结果汇总
-	content := input[0].Content
-	
-	summary := fmt.Sprintf("任务执行完成！\n\n执行过程：\n%s\n\n任务已添加到工作空间。", content)
 
-	return &schema.Message{
-		Role:    "assistant",
-		Content: summary,
-	}, nil
-}
-
-func (c *OrchestratorSummaryComponent) Invoke(ctx context.Context, input schema.Message) (schema.Message, error) {
-	// 结果汇总
-	content := input.Content
-	
-	summary := fmt.Sprintf("任务执行完成！\n\n执行过程：\n%s\n\n任务已添加到工作空间。", content)
+	executor, exists := GetActionRegistry().Get(action)
+	if !exists {
+		return "", fmt.Errorf("未知的 action: %s，可用的 action: %s", action, strings.Join(GetActionRegistry().ListActions(), ", "))
+	}
 
-	return schema.Message{
-		Role:    "assistant",
-		Content: summary,
-	}, nil
-}
+	references, err := resolveActionReferences(action, instruction, o.workspace)
+	if err != nil {
+		return "", err
+	}
 
-func (c *OrchestratorSummaryComponent) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	response, err := c.Generate(ctx, input, opts...)
+	ctx = models.WithUsageTags(ctx, models.UsageTags{Action: action})
+	streamCtx, streamDone := o.withActionStream(ctx, round, action)
+	result, err := executor.Execute(streamCtx, ActionRequest{
+		Round:       round,
+		Action:      action,
+		Instruction: instruction,
+		Task:        task,
+		References:  references,
+	})
+	streamDone()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		writer.Send(response, nil)
-	}()
-	
-	return reader, nil
-}
 
-func (c *OrchestratorSummaryComponent) Collect(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
-	var content strings.Builder
-	for {
-		msg, err := input.Recv()
+	// 笔记序号由工作空间统一分配，模型输出中的序号只用于排序
+	var b strings.Builder
+	for _, actionNote := range result.Notes {
+		note, err := o.workspace.SaveNote(core.Note{
+			Type:        actionNote.Tag,
+			Action:      action,
+			Round:       round,
+			Instruction: instruction,
+			Content:     actionNote.Content,
+			Sources:     actionNote.Sources,
+			Deliverable: actionNote.Deliverable,
+		})
 		if err != nil {
-			break
+			return "", fmt.Errorf("保存笔记失败: %v", err)
 		}
-		content.WriteString(msg.Content)
+		b.WriteString(note.Render())
+		b.WriteString("\n")
 	}
-	
-	return &schema.Message{
-		Role:    "assistant",
-		Content: content.String(),
-	}, nil
-}
-
-func (c *OrchestratorSummaryComponent) Transform(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
-	// 创建一个简单的流适配器
-	reader, writer := schema.Pipe[*schema.Message](5)
-	
-	go func() {
-		defer writer.Close()
-		
-		for {
-			msg, err := input.Recv()
-			if err != nil {
-				writer.Send(msg, err)
-				break
-			}
-			writer.Send(msg, nil)
-		}
-	}()
-	
-	return reader, nil
+	for _, problem := range result.Problems {
+		b.WriteString(fmt.Sprintf("[未保存] %s\n", problem))
+	}
+	return b.String(), nil
 }
 
-func (c *OrchestratorSummaryComponent) GetInputType() string {
-	return "message"
-}
+type orchestratorJobKey struct{}
 
-func (c *OrchestratorSummaryComponent) GetOutputType() string {
-	return "message"
+// withOrchestratorJob 把后台任务句柄放入 context，供 ReAct 循环读取注入的反馈
+func withOrchestratorJob(ctx context.Context, job *OrchestratorJob) context.Context {
+	return context.WithValue(ctx, orchestratorJobKey{}, job)
 }
 
-func (c *OrchestratorSummaryComponent) GetOptionType() string {
-	return "config"
+func orchestratorJobFromContext(ctx context.Context) *OrchestratorJob {
+	job, _ := ctx.Value(orchestratorJobKey{}).(*OrchestratorJob)
+	return job
 }
 
-func (c *OrchestratorSummaryComponent) SetOption(option any) error {
-	return nil
-}
-
-func (c *OrchestratorSummaryComponent) GetOption() any {
-	return nil
-}
+// formatRunResult 汇总本次运行的结果，没有输出 <task_completed/> 就用完轮次时如实说明
+func (o *Orchestrator) formatRunResult(state *TaskState) string {
+	var b strings.Builder
+	if state.Completed {
+		b.WriteString(fmt.Sprintf("任务执行完成，共 %d 轮：\n", len(state.Rounds)))
+	} else {
+		b.WriteString(fmt.Sprintf("已达到轮次上限（%d 轮），任务尚未完成，以下为目前的进展：\n", state.MaxRounds))
+	}
 
-func (c *OrchestratorSummaryComponent) SetCallbacks(callbacks callbacks.Handler) error {
-	return nil
-}
+	lastResult := ""
+	for _, record := range state.Rounds {
+		if !record.Step.HasAction() {
+			continue
+		}
+		tactic := core.Tactic{Round: record.Round, Action: record.Step.Action, Memo: record.Step.Tactics}
+		b.WriteString(tactic.String())
+		b.WriteString("\n")
+		if record.Result != "" {
+			lastResult = record.Result
+		}
+	}
 
-func (c *OrchestratorSummaryComponent) GetCallbacks() callbacks.Handler {
-	return nil
+	if lastResult != "" {
+		b.WriteString("\n最终产出：\n")
+		b.WriteString(lastResult)
+	}
+	return b.String()
 }
-
- 
\ No newline at end of file
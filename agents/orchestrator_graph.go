@@ -0,0 +1,85 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/core"
+)
+
+// RoundRecord 单轮 ReAct 执行记录
+type RoundRecord struct {
+	Round  int       // 会话内全局轮次编号
+	Step   ReActStep // 编排器输出的解析结果
+	Result string    // 行动产出（渲染后的笔记）
+	Err    error     // 行动执行失败的原因
+}
+
+// TaskState 编排图节点之间传递的任务状态
+type TaskState struct {
+	Task      string        // 用户任务
+	MaxRounds int           // decomposition 节点给出的轮次预算
+	Rounds    []RoundRecord // execution 节点的执行记录
+	Completed bool          // 编排器是否主动输出了 <task_completed/>
+}
+
+// OrchestratorAnalysisComponent 编排器分析组件：从输入消息中提取任务
+type OrchestratorAnalysisComponent struct {
+	orchestrator *Orchestrator
+}
+
+// Invoke 分析任务
+func (c *OrchestratorAnalysisComponent) Invoke(ctx context.Context, input []*schema.Message) (*TaskState, error) {
+	task := ""
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i] != nil && input[i].Role == schema.User {
+			task = strings.TrimSpace(input[i].Content)
+			break
+		}
+	}
+	if task == "" {
+		return nil, fmt.Errorf("空输入")
+	}
+
+	// 添加任务到工作空间，并作为任务消息进入时间线
+	c.orchestrator.workspace.AddTask(task)
+	c.orchestrator.conversation.AddMessage(core.MessageRoleTask, task)
+
+	return &TaskState{Task: task}, nil
+}
+
+// OrchestratorDecompositionComponent 编排器分解组件：分配轮次预算
+type OrchestratorDecompositionComponent struct {
+	orchestrator *Orchestrator
+}
+
+// Invoke 分配轮次预算，使用编排器配置的轮次上限
+func (c *OrchestratorDecompositionComponent) Invoke(ctx context.Context, state *TaskState) (*TaskState, error) {
+	state.MaxRounds = c.orchestrator.MaxRounds()
+	return state, nil
+}
+
+// OrchestratorExecutionComponent 编排器执行组件：运行 ReAct 循环
+type OrchestratorExecutionComponent struct {
+	orchestrator *Orchestrator
+}
+
+// Invoke 执行任务
+func (c *OrchestratorExecutionComponent) Invoke(ctx context.Context, state *TaskState) (*TaskState, error) {
+	if err := c.orchestrator.runReAct(ctx, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// OrchestratorSummaryComponent 编排器汇总组件：把执行记录汇总为最终回复
+type OrchestratorSummaryComponent struct {
+	orchestrator *Orchestrator
+}
+
+// Invoke 汇总结果
+func (c *OrchestratorSummaryComponent) Invoke(ctx context.Context, state *TaskState) (*schema.Message, error) {
+	return schema.AssistantMessage(c.orchestrator.formatRunResult(state), nil), nil
+}
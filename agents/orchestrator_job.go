@@ -0,0 +1,139 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"loomi2.0/models"
+)
+
+// JobStatus 编排任务的运行状态
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// OrchestratorJob 在后台运行的编排任务句柄
+type OrchestratorJob struct {
+	ID        string
+	Task      string
+	StartedAt time.Time
+
+	mu         sync.RWMutex
+	status     JobStatus
+	result     string
+	err        error
+	feedback   []string
+	finishedAt time.Time
+	cancel     context.CancelFunc
+	done       chan struct{}
+	budget     *models.Budget
+}
+
+func newOrchestratorJob(id, task string, cancel context.CancelFunc, budget *models.Budget) *OrchestratorJob {
+	return &OrchestratorJob{
+		ID:        id,
+		Task:      task,
+		StartedAt: time.Now(),
+		status:    JobStatusRunning,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		budget:    budget,
+	}
+}
+
+// Budget 任务预算
+func (j *OrchestratorJob) Budget() *models.Budget {
+	return j.budget
+}
+
+// Status 获取任务状态
+func (j *OrchestratorJob) Status() JobStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.status
+}
+
+// IsRunning 检查任务是否仍在运行
+func (j *OrchestratorJob) IsRunning() bool {
+	return j.Status() == JobStatusRunning
+}
+
+// Result 获取任务结果，任务未结束时返回空字符串
+func (j *OrchestratorJob) Result() (string, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.result, j.err
+}
+
+// FinishedAt 获取任务结束时间，任务未结束时为零值
+func (j *OrchestratorJob) FinishedAt() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.finishedAt
+}
+
+// Done 任务结束时关闭的通道
+func (j *OrchestratorJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait 等待任务结束并返回结果
+func (j *OrchestratorJob) Wait(ctx context.Context) (string, error) {
+	select {
+	case <-j.done:
+		return j.Result()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Cancel 取消任务，编排器会在当前模型调用返回后停止
+func (j *OrchestratorJob) Cancel() {
+	j.cancel()
+}
+
+// addFeedback 记录运行中注入的用户反馈，等待下一轮取走
+func (j *OrchestratorJob) addFeedback(feedback string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.feedback = append(j.feedback, feedback)
+}
+
+// takeFeedback 取走自上一轮以来注入的用户反馈
+func (j *OrchestratorJob) takeFeedback() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	feedback := j.feedback
+	j.feedback = nil
+	return feedback
+}
+
+// finish 记录任务结果并通知等待者
+func (j *OrchestratorJob) finish(ctx context.Context, result string, err error) {
+	j.mu.Lock()
+	j.result = result
+	j.err = err
+	j.finishedAt = time.Now()
+	switch {
+	case err != nil && ctx.Err() == context.Canceled:
+		j.status = JobStatusCancelled
+	case err != nil:
+		j.status = JobStatusFailed
+	default:
+		j.status = JobStatusCompleted
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// String 任务的简要描述
+func (j *OrchestratorJob) String() string {
+	return fmt.Sprintf("%s [%s] %s", j.ID, j.Status(), truncateRunes(j.Task, 40))
+}
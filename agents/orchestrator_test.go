@@ -0,0 +1,147 @@
+package agents
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/core"
+	"loomi2.0/models"
+)
+
+func TestFormatRunResult(t *testing.T) {
+	o := &Orchestrator{}
+	rounds := []RoundRecord{
+		{Round: 1, Step: ReActStep{Action: "insight", Instruction: "分析"}, Result: "[insight1] 洞察"},
+		{Round: 2, Step: ReActStep{Action: "xhs_post", Instruction: "写帖子"}, Result: "[xhs_post1] 帖子"},
+	}
+
+	tests := []struct {
+		name      string
+		state     *TaskState
+		want      string
+		notWanted string
+	}{
+		{
+			name:      "主动完成",
+			state:     &TaskState{MaxRounds: 4, Rounds: rounds, Completed: true},
+			want:      "任务执行完成，共 2 轮",
+			notWanted: "轮次上限",
+		},
+		{
+			name:      "用完轮次",
+			state:     &TaskState{MaxRounds: 2, Rounds: rounds},
+			want:      "已达到轮次上限（2 轮），任务尚未完成",
+			notWanted: "任务执行完成",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := o.formatRunResult(tt.state)
+			if !strings.Contains(got, tt.want) || strings.Contains(got, tt.notWanted) {
+				t.Errorf("汇总应当包含 %q 且不包含 %q，实际:\n%s", tt.want, tt.notWanted, got)
+			}
+			if !strings.Contains(got, "最终产出：\n[xhs_post1] 帖子") {
+				t.Errorf("汇总应当附上最后一次产出，实际:\n%s", got)
+			}
+		})
+	}
+}
+
+// newTestOrchestrator 创建使用全局工作空间和对话管理器的编排器，测试结束后清空
+func newTestOrchestrator(t *testing.T) *Orchestrator {
+	t.Helper()
+	if err := core.InitWorkspace(); err != nil {
+		t.Fatalf("初始化工作空间失败: %v", err)
+	}
+	if err := core.InitConversationManager(); err != nil {
+		t.Fatalf("初始化对话管理器失败: %v", err)
+	}
+	workspace := core.GetWorkspace()
+	conversation := core.GetConversationManager()
+	workspace.Clear()
+	conversation.Clear()
+	t.Cleanup(workspace.Clear)
+	t.Cleanup(conversation.Clear)
+
+	o := &Orchestrator{
+		workspace:        workspace,
+		conversation:     conversation,
+		maxRounds:        DefaultMaxRounds,
+		contextBuilder:   NewContextBuilder(workspace, conversation, DefaultContextLimits),
+		jobUpdates:       make(chan *OrchestratorJob, 8),
+		approvalRequests: make(chan *ApprovalRequest, 1),
+		streamChunks:     make(chan StreamChunk, 64),
+	}
+	if err := o.init(); err != nil {
+		t.Fatalf("初始化编排器失败: %v", err)
+	}
+	return o
+}
+
+// readStream 读取流中的全部分片
+func readStream(t *testing.T, reader *schema.StreamReader[*schema.Message]) []string {
+	t.Helper()
+	defer reader.Close()
+
+	var chunks []string
+	for {
+		msg, err := reader.Recv()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("读取流失败: %v", err)
+		}
+		chunks = append(chunks, msg.Content)
+	}
+}
+
+// lineChunks 假模型流式输出时按行拆出的分片
+func lineChunks(outputs ...string) []string {
+	var chunks []string
+	for _, output := range outputs {
+		for _, line := range strings.SplitAfter(output, "\n") {
+			if line != "" {
+				chunks = append(chunks, line)
+			}
+		}
+	}
+	return chunks
+}
+
+func TestProcessTaskStreamChunkOrder(t *testing.T) {
+	plan := "<tactics>先做洞察</tactics>\n<execute_step action=\"insight\" instruction=\"分析受众\"/>"
+	insight := "<insight1>\n怕被说教\n</insight1>"
+	done := "<tactics>完成</tactics>\n<task_completed/>"
+	useFakeModel(t, &models.FakeScript{
+		Rules: []*models.FakeRule{
+			{Name: "orchestrator", System: "Orchestrator（编排员）", Responses: []string{plan, done}},
+			{Name: "insight", System: "一步一步收束推导出好的洞察", Responses: []string{insight}},
+		},
+	})
+	o := newTestOrchestrator(t)
+
+	reader, err := o.ProcessTaskStream(context.Background(), "写一篇帖子")
+	if err != nil {
+		t.Fatalf("流式处理任务失败: %v", err)
+	}
+	chunks := readStream(t, reader)
+
+	// 编排、行动、编排的模型输出按调用顺序逐段到达，最后是汇总
+	want := lineChunks(plan, insight, done)
+	if len(chunks) != len(want)+1 || !reflect.DeepEqual(chunks[:len(want)], want) {
+		t.Fatalf("分片顺序错误，期望 %q 加汇总，实际 %q", want, chunks)
+	}
+	summary := chunks[len(chunks)-1]
+	if !strings.HasPrefix(summary, "\n任务执行完成，共 2 轮") {
+		t.Errorf("最后一段应当是汇总，实际 %q", summary)
+	}
+	messages := o.conversation.GetMessages()
+	if last := messages[len(messages)-1]; last.Content != strings.TrimPrefix(summary, "\n") {
+		t.Errorf("汇总应当记入对话历史，实际 %q", last.Content)
+	}
+}
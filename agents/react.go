@@ -0,0 +1,54 @@
+package agents
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ReActStep 编排器单轮输出的解析结果
+type ReActStep struct {
+	Tactics     string // <tactics> 中的战术备忘
+	Action      string // <execute_step> 的 action 属性
+	Instruction string // <execute_step> 的 instruction 属性
+	Completed   bool   // 是否输出了 <task_completed/>
+	Raw         string // 模型原始输出
+}
+
+var (
+	tacticsPattern       = regexp.MustCompile(`(?s)<tactics>(.*?)</tactics>`)
+	// 属性值可以用双引号、单引号或不加引号，引号内可以包含 > 和换行
+	executeStepPattern   = regexp.MustCompile(`<execute_step((?:\s+\w+\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'>]+?))*)\s*/?>`)
+	stepAttributePattern = regexp.MustCompile(`(\w+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	taskCompletedPattern = regexp.MustCompile(`<task_completed\s*/?>`)
+)
+
+// ParseReActStep 解析编排器输出中的 <tactics>、<execute_step/> 和 <task_completed/> 标签
+func ParseReActStep(output string) ReActStep {
+	step := ReActStep{Raw: output}
+
+	if match := tacticsPattern.FindStringSubmatch(output); match != nil {
+		step.Tactics = strings.TrimSpace(match[1])
+	}
+
+	// 每轮只允许执行一个步骤，多余的 execute_step 会被忽略
+	if match := executeStepPattern.FindStringSubmatch(output); match != nil {
+		for _, attr := range stepAttributePattern.FindAllStringSubmatch(match[1], -1) {
+			// 三个分组分别对应双引号、单引号和不加引号的值，只有一个非空
+			value := strings.TrimSpace(attr[2] + attr[3] + attr[4])
+			switch attr[1] {
+			case "action":
+				step.Action = value
+			case "instruction":
+				step.Instruction = value
+			}
+		}
+	}
+
+	step.Completed = taskCompletedPattern.MatchString(output)
+	return step
+}
+
+// HasAction 是否包含需要执行的步骤
+func (s ReActStep) HasAction() bool {
+	return s.Action != ""
+}
@@ -0,0 +1,117 @@
+package agents
+
+import "testing"
+
+func TestParseReActStepAttributes(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		action      string
+		instruction string
+	}{
+		{
+			name:        "双引号",
+			output:      `<execute_step action="insight" instruction="分析职场新人的痛点"/>`,
+			action:      "insight",
+			instruction: "分析职场新人的痛点",
+		},
+		{
+			name:        "单引号",
+			output:      `<execute_step action='profile' instruction='找出"效率焦虑"人群'/>`,
+			action:      "profile",
+			instruction: `找出"效率焦虑"人群`,
+		},
+		{
+			name:        "不加引号",
+			output:      `<execute_step action=xhs_post instruction=写一篇帖子/>`,
+			action:      "xhs_post",
+			instruction: "写一篇帖子",
+		},
+		{
+			name:        "指令中包含大于号",
+			output:      `<execute_step action="insight" instruction="对比 A > B 的场景，输出 <b>要点</b> -> 结论"/>`,
+			action:      "insight",
+			instruction: "对比 A > B 的场景，输出 <b>要点</b> -> 结论",
+		},
+		{
+			name:        "跨行指令",
+			output:      "<execute_step\n  action=\"xhs_post\"\n  instruction=\"第一行\n第二行\"\n/>",
+			action:      "xhs_post",
+			instruction: "第一行\n第二行",
+		},
+		{
+			name:   "没有步骤",
+			output: "<tactics>先想一想</tactics>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := ParseReActStep(tt.output)
+			if step.Action != tt.action || step.Instruction != tt.instruction {
+				t.Errorf("期望 action=%q instruction=%q，实际 action=%q instruction=%q",
+					tt.action, tt.instruction, step.Action, step.Instruction)
+			}
+		})
+	}
+}
+
+func TestParseReActStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		tactics   string
+		action    string
+		completed bool
+	}{
+		{
+			name:    "战术加步骤",
+			output:  "<tactics>\n先找画像，再写帖子。\n</tactics>\n<execute_step action=\"profile\" instruction=\"分析画像\"/>",
+			tactics: "先找画像，再写帖子。",
+			action:  "profile",
+		},
+		{
+			name:      "完成",
+			output:    "<tactics>交付已齐</tactics>\n<task_completed/>",
+			tactics:   "交付已齐",
+			completed: true,
+		},
+		{
+			name:      "完成标签带空格",
+			output:    "<task_completed />",
+			completed: true,
+		},
+		{
+			name:   "多个步骤只取第一个",
+			output: `<execute_step action="insight" instruction="一"/><execute_step action="xhs_post" instruction="二"/>`,
+			action: "insight",
+		},
+		{
+			name:   "没有标签",
+			output: "我需要更多信息",
+		},
+		{
+			name:    "跨行战术",
+			output:  "<tactics>第一步\n第二步</tactics>",
+			tactics: "第一步\n第二步",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := ParseReActStep(tt.output)
+			if step.Tactics != tt.tactics {
+				t.Errorf("tactics 期望 %q，实际 %q", tt.tactics, step.Tactics)
+			}
+			if step.Action != tt.action || step.HasAction() != (tt.action != "") {
+				t.Errorf("action 期望 %q，实际 %q", tt.action, step.Action)
+			}
+			if step.Completed != tt.completed {
+				t.Errorf("completed 期望 %v，实际 %v", tt.completed, step.Completed)
+			}
+			if step.Raw != tt.output {
+				t.Errorf("Raw 应当保留原始输出")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package agents
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"loomi2.0/core"
+)
+
+// MaxNoteReferences 单条指令中最多允许的 @ 引用数量（@material 不计入）
+const MaxNoteReferences = 2
+
+var referencePattern = regexp.MustCompile(`@([A-Za-z_]+)(\d*)`)
+
+// noteReference 指令中的一个笔记引用
+type noteReference struct {
+	start, end int    // 在指令中的位置，包含 @
+	noteType   string // 笔记类型，已转为小写
+	index      string // 序号，@material 可以为空
+}
+
+// knownNoteTypes 可以被 @ 引用的笔记类型：注册表中各行动产出的笔记类型、材料和无类型笔记
+func knownNoteTypes() map[string]bool {
+	types := map[string]bool{core.MaterialNoteType: true, core.DefaultNoteType: true}
+	for _, noteType := range GetActionRegistry().NoteTypes() {
+		types[noteType] = true
+	}
+	return types
+}
+
+// findReferences 找出指令中的笔记引用：@ 后是已知的笔记类型，且 @ 不紧跟在字母、数字或点之后，
+// 邮箱（a@b.com）和小红书的 @用户名 等其它 @ 文本不视为引用
+func findReferences(instruction string) []noteReference {
+	types := knownNoteTypes()
+	var references []noteReference
+	for _, match := range referencePattern.FindAllStringSubmatchIndex(instruction, -1) {
+		if start := match[0]; start > 0 && isReferenceBoundary(instruction[start-1]) {
+			continue
+		}
+		if end := match[1]; end < len(instruction) && isReferenceBoundary(instruction[end]) {
+			continue
+		}
+		noteType := strings.ToLower(instruction[match[2]:match[3]])
+		if !types[noteType] {
+			continue
+		}
+		references = append(references, noteReference{
+			start:    match[0],
+			end:      match[1],
+			noteType: noteType,
+			index:    instruction[match[4]:match[5]],
+		})
+	}
+	return references
+}
+
+// isReferenceBoundary 紧邻 @ 引用时说明这不是引用的字符：字母、数字、下划线、点和 @
+func isReferenceBoundary(c byte) bool {
+	return c == '_' || c == '.' || c == '@' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// stripReferences 去掉指令中的笔记引用，其它 @ 文本保持不变
+func stripReferences(instruction string) string {
+	references := findReferences(instruction)
+	var b strings.Builder
+	last := 0
+	for _, ref := range references {
+		b.WriteString(instruction[last:ref.start])
+		last = ref.end
+	}
+	b.WriteString(instruction[last:])
+	return b.String()
+}
+
+// ResolveReferences 解析指令中的 @ 引用并在工作空间中校验
+// 不带序号的 @material 表示引用全部材料；其它引用必须带序号，例如 @profile2
+// 只有 @ 后是已知笔记类型时才视为引用，邮箱和 @用户名 等不受影响
+func ResolveReferences(instruction string, workspace *core.WorkSpace) ([]core.Note, error) {
+	var (
+		references []core.Note
+		unknown    []string
+		counted    []string
+		seen       = make(map[string]bool)
+	)
+
+	for _, ref := range findReferences(instruction) {
+		noteType := ref.noteType
+		id := noteType + ref.index
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if ref.index == "" {
+			if noteType != core.MaterialNoteType {
+				unknown = append(unknown, "@"+id)
+				continue
+			}
+			for _, note := range workspace.ListNotes() {
+				if note.Type == core.MaterialNoteType && !seen[note.ID] {
+					seen[note.ID] = true
+					references = append(references, note)
+				}
+			}
+			continue
+		}
+
+		note, exists := workspace.GetNote(id)
+		if !exists {
+			unknown = append(unknown, "@"+id)
+			continue
+		}
+		if note.Type != core.MaterialNoteType {
+			counted = append(counted, "@"+id)
+		}
+		references = append(references, note)
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("引用的笔记不存在: %s，请只引用[created_notes]中已有的笔记", strings.Join(unknown, ", "))
+	}
+	if len(counted) > MaxNoteReferences {
+		return nil, fmt.Errorf("instruction 最多只能包含%d个@引用（@material 除外），实际为%d个: %s",
+			MaxNoteReferences, len(counted), strings.Join(counted, ", "))
+	}
+	return references, nil
+}
+
+// ReferenceResolver 需要自行决定如何解析指令中 @ 引用的执行器实现该接口
+type ReferenceResolver interface {
+	ResolveReferences(instruction string, workspace *core.WorkSpace) ([]core.Note, error)
+}
+
+// resolveActionReferences 按执行器的方式解析指令中的 @ 引用，执行器没有特殊处理时使用 ResolveReferences
+func resolveActionReferences(action, instruction string, workspace *core.WorkSpace) ([]core.Note, error) {
+	if executor, exists := GetActionRegistry().Get(action); exists {
+		if resolver, ok := executor.(ReferenceResolver); ok {
+			return resolver.ResolveReferences(instruction, workspace)
+		}
+	}
+	return ResolveReferences(instruction, workspace)
+}
@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"loomi2.0/core"
+)
+
+// newTestWorkspace 创建包含一条画像笔记和一条材料的工作空间
+func newTestWorkspace(t *testing.T) *core.WorkSpace {
+	t.Helper()
+	if err := core.InitWorkspace(); err != nil {
+		t.Fatalf("初始化工作空间失败: %v", err)
+	}
+	workspace := core.GetWorkspace()
+	workspace.Clear()
+	t.Cleanup(workspace.Clear)
+
+	for _, note := range []core.Note{
+		{Type: "profile", Action: "profile", Content: "职场新人"},
+		{Type: core.MaterialNoteType, Action: "content_analysis", Content: "粘贴的原文"},
+	} {
+		if _, err := workspace.SaveNote(note); err != nil {
+			t.Fatalf("保存笔记失败: %v", err)
+		}
+	}
+	return workspace
+}
+
+func TestResolveReferences(t *testing.T) {
+	workspace := newTestWorkspace(t)
+
+	tests := []struct {
+		name        string
+		instruction string
+		want        []string
+		err         string
+	}{
+		{name: "笔记引用", instruction: "基于 @profile1 写帖子", want: []string{"profile1"}},
+		{name: "中文紧邻", instruction: "参考@profile1的痛点", want: []string{"profile1"}},
+		{name: "全部材料", instruction: "拆解 @material", want: []string{"material1"}},
+		{name: "大写类型", instruction: "基于 @Profile1", want: []string{"profile1"}},
+		{name: "重复引用只算一次", instruction: "@profile1 和 @profile1", want: []string{"profile1"}},
+		{name: "邮箱", instruction: "联系 editor@loomi.com 获取授权", want: nil},
+		{name: "小红书用户", instruction: "参考 @小红书薯队长 和 @Nike 的笔记", want: nil},
+		{name: "用户名中带已知类型", instruction: "参考 @profile_king 的账号", want: nil},
+		{name: "不存在的笔记", instruction: "基于 @profile9", err: "@profile9"},
+		{name: "已知类型缺少序号", instruction: "基于 @insight 写", err: "@insight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := ResolveReferences(tt.instruction, workspace)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("期望包含 %q 的错误，实际 %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不应返回错误: %v", err)
+			}
+			var ids []string
+			for _, note := range notes {
+				ids = append(ids, note.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("期望引用 %v，实际 %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func TestResolveReferencesLimit(t *testing.T) {
+	workspace := newTestWorkspace(t)
+	for i := 0; i < 2; i++ {
+		if _, err := workspace.SaveNote(core.Note{Type: "insight", Action: "insight", Content: "洞察"}); err != nil {
+			t.Fatalf("保存笔记失败: %v", err)
+		}
+	}
+	if _, err := ResolveReferences("@profile1 @insight1 @insight2 @material", workspace); err == nil || !strings.Contains(err.Error(), "最多只能包含") {
+		t.Errorf("超过引用上限应当报错，实际 %v", err)
+	}
+}
+
+func TestStripReferences(t *testing.T) {
+	got := stripReferences("@profile1 职场新人 效率工具 联系 a@b.com @Nike")
+	if want := " 职场新人 效率工具 联系 a@b.com @Nike"; got != want {
+		t.Errorf("期望 %q，实际 %q", want, got)
+	}
+}
+
+func TestContentAnalysisPastedMaterial(t *testing.T) {
+	workspace := newTestWorkspace(t)
+	pasted := strings.Repeat("早八人必备的三个效率神器，", 10) + "感谢 @小红书成长助手 @profile9 推荐，合作请联系 biz@brand.com"
+
+	references, err := resolveActionReferences("content_analysis", pasted, workspace)
+	if err != nil || len(references) != 0 {
+		t.Errorf("粘贴的原文不应解析为引用: %v %v", references, err)
+	}
+
+	// 引用已有笔记的长指令仍然正常解析
+	references, err = resolveActionReferences("content_analysis", strings.Repeat("拆解", 60)+" @profile1", workspace)
+	if err != nil || len(references) != 1 {
+		t.Errorf("长指令中的有效引用应当解析: %v %v", references, err)
+	}
+
+	// 短指令不是粘贴的原文，引用错误照常报告
+	if _, err := resolveActionReferences("content_analysis", "拆解 @profile9", workspace); err == nil {
+		t.Error("短指令中不存在的引用应当报错")
+	}
+}
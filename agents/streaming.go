@@ -0,0 +1,134 @@
+package agents
+
+import (
+	"context"
+	"strings"
+
+	"loomi2.0/models"
+)
+
+// StreamChunk 编排任务执行行动时模型输出的一段文本
+type StreamChunk struct {
+	JobID   string
+	Round   int
+	Action  string
+	Content string
+	Done    bool // 本次行动的模型输出结束
+}
+
+// SetStreaming 开启或关闭流式输出：开启后行动执行时模型输出逐段推送到 StreamChunks
+func (o *Orchestrator) SetStreaming(enabled bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.streaming = enabled
+}
+
+// Streaming 检查是否开启了流式输出
+func (o *Orchestrator) Streaming() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.streaming
+}
+
+// StreamChunks 行动执行中推送模型输出的通道，开启流式输出后需要持续读取
+func (o *Orchestrator) StreamChunks() <-chan StreamChunk {
+	return o.streamChunks
+}
+
+// withActionStream 开启流式输出时返回把模型输出推送到 StreamChunks 的 ctx，以及结束时调用的 done
+func (o *Orchestrator) withActionStream(ctx context.Context, round int, action string) (context.Context, func()) {
+	if !o.Streaming() {
+		return ctx, func() {}
+	}
+
+	chunk := StreamChunk{Round: round, Action: action}
+	if job := orchestratorJobFromContext(ctx); job != nil {
+		chunk.JobID = job.ID
+	}
+	send := func(c StreamChunk) {
+		select {
+		case o.streamChunks <- c:
+		case <-ctx.Done():
+		}
+	}
+
+	streamCtx := models.WithStreamHandler(ctx, func(content string) {
+		c := chunk
+		c.Content = content
+		send(c)
+	})
+	return streamCtx, func() {
+		c := chunk
+		c.Done = true
+		send(c)
+	}
+}
+
+// hiddenTagFilter 流式输出时隐藏 open 与 close 之间的内容，例如 <call_orchestrator> 工具调用
+type hiddenTagFilter struct {
+	open   string
+	close  string
+	emit   models.StreamHandler
+	buf    string
+	hidden bool
+}
+
+func newHiddenTagFilter(open, close string, emit models.StreamHandler) *hiddenTagFilter {
+	return &hiddenTagFilter{open: open, close: close, emit: emit}
+}
+
+// Write 处理一段输出；可能是标签开头的部分先缓存，确定不是标签后再输出
+func (f *hiddenTagFilter) Write(chunk string) {
+	f.buf += chunk
+	for {
+		if f.hidden {
+			i := strings.Index(f.buf, f.close)
+			if i < 0 {
+				f.buf = f.buf[len(f.buf)-partialSuffix(f.buf, f.close):]
+				return
+			}
+			f.buf = f.buf[i+len(f.close):]
+			f.hidden = false
+			continue
+		}
+
+		if i := strings.Index(f.buf, f.open); i >= 0 {
+			f.output(f.buf[:i])
+			f.buf = f.buf[i+len(f.open):]
+			f.hidden = true
+			continue
+		}
+		keep := partialSuffix(f.buf, f.open)
+		f.output(f.buf[:len(f.buf)-keep])
+		f.buf = f.buf[len(f.buf)-keep:]
+		return
+	}
+}
+
+// Flush 输出缓存中剩余的文本
+func (f *hiddenTagFilter) Flush() {
+	if !f.hidden {
+		f.output(f.buf)
+	}
+	f.buf = ""
+}
+
+func (f *hiddenTagFilter) output(text string) {
+	if text != "" {
+		f.emit(text)
+	}
+}
+
+// partialSuffix s 的结尾与 tag 开头重合的最长长度
+func partialSuffix(s, tag string) int {
+	n := len(tag) - 1
+	if len(s) < n {
+		n = len(s)
+	}
+	for ; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"loomi2.0/models"
+)
+
+func TestActionStreamStopsOnCancel(t *testing.T) {
+	o := &Orchestrator{streaming: true, streamChunks: make(chan StreamChunk)}
+	ctx, cancel := context.WithCancel(context.Background())
+	streamCtx, done := o.withActionStream(ctx, 1, "insight")
+	cancel()
+
+	// 没有人读取 StreamChunks 时，任务取消后推送不能阻塞
+	finished := make(chan struct{})
+	go func() {
+		models.StreamHandlerFromContext(streamCtx)("片段")
+		done()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("任务取消后推送流式输出仍然阻塞")
+	}
+}
@@ -0,0 +1,129 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"loomi2.0/models"
+	"loomi2.0/prompts"
+	"loomi2.0/tools"
+)
+
+// WebSearchAction 搜索行动：调用工具管理器搜索，并把结果浓缩为带来源的 websearch 笔记
+type WebSearchAction struct {
+	toolName string
+}
+
+// NewWebSearchAction 创建搜索行动；toolName 为空时同时使用所有搜索工具
+func NewWebSearchAction(toolName string) *WebSearchAction {
+	return &WebSearchAction{toolName: toolName}
+}
+
+// Name 行动名称
+func (a *WebSearchAction) Name() string {
+	return "websearch"
+}
+
+// Description 行动描述
+func (a *WebSearchAction) Description() string {
+	return "搜索公域互联网信息并整理为带来源的要点"
+}
+
+// Execute 执行搜索行动
+func (a *WebSearchAction) Execute(ctx context.Context, req ActionRequest) (*ActionResult, error) {
+	toolManager := tools.GetToolManager()
+	if toolManager == nil {
+		return nil, fmt.Errorf("工具管理器未初始化")
+	}
+	modelManager := models.GetModelManager()
+	if modelManager == nil {
+		return nil, fmt.Errorf("模型管理器未初始化")
+	}
+
+	query := strings.TrimSpace(stripReferences(req.Instruction))
+	if query == "" {
+		return nil, fmt.Errorf("搜索指令为空")
+	}
+
+	responses, err := toolManager.PerformStructuredSearch(ctx, query, a.toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 为所有结果统一编号，供模型引用
+	var results []tools.SearchResult
+	for _, response := range responses {
+		results = append(results, response.Results...)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("没有搜索到与 %s 相关的结果", query)
+	}
+
+	output, err := modelManager.CallCurrentModel(ctx, prompts.WebSearchPrompt, buildWebSearchUserPrompt(req, results), nil)
+	if err != nil {
+		return nil, fmt.Errorf("AI 模型调用失败: %w", err)
+	}
+
+	tagged := ParseTaggedNotes(output, "websearch")
+	if len(tagged) == 0 {
+		return nil, fmt.Errorf("websearch 输出中没有找到 <websearch数字> 标签")
+	}
+
+	notes := make([]ActionNote, 0, len(tagged))
+	for _, note := range tagged {
+		summary := extractTag(note.Content, "summary")
+		if summary == "" {
+			summary = note.Content
+		}
+		sources := resolveSearchSources(extractTag(note.Content, "sources"), results)
+		if len(sources) == 0 {
+			// 模型没有注明来源时保留全部链接，避免来源丢失
+			for _, result := range results {
+				sources = append(sources, result.URL)
+			}
+		}
+
+		note.Content = summary + "\n来源: " + strings.Join(sources, " ")
+		note.Sources = sources
+		notes = append(notes, note)
+	}
+
+	return &ActionResult{Notes: notes, Raw: output}, nil
+}
+
+// buildWebSearchUserPrompt 构建搜索结果整理的用户提示词
+func buildWebSearchUserPrompt(req ActionRequest, results []tools.SearchResult) string {
+	var b strings.Builder
+	b.WriteString(buildActionUserPrompt(req))
+	b.WriteString("\n## 搜索结果\n")
+	for i, result := range results {
+		b.WriteString(fmt.Sprintf("[%d] %s\n%s\n链接: %s\n", i+1, result.Title, result.Snippet, result.URL))
+		if result.PublishedAt != "" {
+			b.WriteString(fmt.Sprintf("发布时间: %s\n", result.PublishedAt))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// resolveSearchSources 把 "1, 3" 形式的编号映射为去重后的来源链接
+func resolveSearchSources(numbers string, results []tools.SearchResult) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(numbers, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '、'
+	}) {
+		index, err := strconv.Atoi(strings.Trim(field, "[]"))
+		if err != nil || index < 1 || index > len(results) {
+			continue
+		}
+		url := results[index-1].URL
+		if url != "" && !seen[url] {
+			seen[url] = true
+			sources = append(sources, url)
+		}
+	}
+	return sources
+}
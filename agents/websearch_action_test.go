@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"loomi2.0/models"
+	"loomi2.0/tools"
+)
+
+// fakeSearchTool 返回固定结果或错误的搜索工具
+type fakeSearchTool struct {
+	name    string
+	results []tools.SearchResult
+	err     error
+	queries []string
+}
+
+func (t *fakeSearchTool) Name() string        { return t.name }
+func (t *fakeSearchTool) Description() string { return "测试搜索工具" }
+func (t *fakeSearchTool) Execute(ctx context.Context, input string) (string, error) {
+	response, err := t.Search(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return tools.FormatSearchResponse(response, t.name), nil
+}
+func (t *fakeSearchTool) Search(ctx context.Context, query string) (*tools.SearchResponse, error) {
+	t.queries = append(t.queries, query)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &tools.SearchResponse{Query: query, Results: t.results, Total: len(t.results), Source: t.name}, nil
+}
+
+// useFakeSearchTool 把搜索工具注册到全局工具管理器，并让 websearch 行动只使用它，测试结束后恢复默认的搜索行动
+func useFakeSearchTool(t *testing.T, tool *fakeSearchTool) {
+	t.Helper()
+	if err := tools.InitToolManager(); err != nil {
+		t.Fatalf("初始化工具管理器失败: %v", err)
+	}
+	tools.GetToolManager().RegisterTool(tool)
+	RegisterAction(NewWebSearchAction(tool.name))
+	t.Cleanup(func() { RegisterAction(NewWebSearchAction("")) })
+}
+
+func TestWebSearchActionSavesNote(t *testing.T) {
+	search := &fakeSearchTool{name: "fake_search_ok", results: []tools.SearchResult{
+		{Title: "防晒霜测评", URL: "https://example.com/1", Snippet: "十款防晒霜横评"},
+		{Title: "无关结果", URL: "https://example.com/2", Snippet: "不相关"},
+		{Title: "成分解析", URL: "https://example.com/3", Snippet: "物理防晒和化学防晒", PublishedAt: "2026-05-01"},
+	}}
+	useFakeSearchTool(t, search)
+	fake := useFakeModel(t, &models.FakeScript{
+		Rules: []*models.FakeRule{{Name: "websearch", System: "严谨的信息研究员", Responses: []string{
+			"<websearch1>\n<summary>通勤党更看重清爽不黏腻。</summary>\n<sources>[1]，3, 3, 9</sources>\n</websearch1>",
+		}}},
+	})
+	o := newTestOrchestrator(t)
+
+	result, err := o.executeAction(context.Background(), 1, "写一篇防晒霜帖子", "websearch", "搜索 2026 防晒霜测评")
+	if err != nil {
+		t.Fatalf("执行搜索行动失败: %v", err)
+	}
+	if len(search.queries) != 1 || search.queries[0] != "搜索 2026 防晒霜测评" {
+		t.Errorf("搜索工具收到的查询错误: %v", search.queries)
+	}
+	user := fake.Calls()[0].User
+	if !strings.Contains(user, "[3] 成分解析\n物理防晒和化学防晒\n链接: https://example.com/3\n发布时间: 2026-05-01\n") {
+		t.Errorf("整理提示词应当包含编号的搜索结果，实际:\n%s", user)
+	}
+
+	note, exists := o.workspace.GetNote("websearch1")
+	if !exists {
+		t.Fatalf("搜索结果应当保存为笔记，实际返回 %q", result)
+	}
+	wantSources := []string{"https://example.com/1", "https://example.com/3"}
+	if !reflect.DeepEqual(note.Sources, wantSources) {
+		t.Errorf("来源期望 %v，实际 %v", wantSources, note.Sources)
+	}
+	if note.Content != "通勤党更看重清爽不黏腻。\n来源: https://example.com/1 https://example.com/3" || note.Action != "websearch" || note.Round != 1 {
+		t.Errorf("笔记内容错误: %+v", note)
+	}
+	if !strings.Contains(result, note.Render()) {
+		t.Errorf("观察中应当包含保存的笔记，实际 %q", result)
+	}
+}
+
+func TestWebSearchActionToolError(t *testing.T) {
+	useFakeSearchTool(t, &fakeSearchTool{name: "fake_search_down", err: fmt.Errorf("配额用尽")})
+	fake := useFakeModel(t, &models.FakeScript{Default: "<websearch1><summary>不应调用</summary></websearch1>"})
+	o := newTestOrchestrator(t)
+
+	_, err := o.executeAction(context.Background(), 1, "写一篇防晒霜帖子", "websearch", "搜索防晒霜测评")
+	if err == nil || err.Error() != "搜索失败: fake_search_down: 配额用尽" {
+		t.Errorf("应当返回搜索工具的错误，实际 %v", err)
+	}
+	if len(fake.Calls()) != 0 {
+		t.Error("搜索失败时不应调用模型整理结果")
+	}
+	if notes := o.workspace.ListNotes(); len(notes) != 0 {
+		t.Errorf("搜索失败时不应保存笔记，实际 %v", notes)
+	}
+
+	// 没有结果时同样报错
+	useFakeSearchTool(t, &fakeSearchTool{name: "fake_search_empty"})
+	if _, err := o.executeAction(context.Background(), 2, "写一篇防晒霜帖子", "websearch", "搜索防晒霜测评"); err == nil || !strings.Contains(err.Error(), "没有搜索到与 搜索防晒霜测评 相关的结果") {
+		t.Errorf("没有搜索结果时应当报错，实际 %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"loomi2.0/core"
+	"loomi2.0/models"
+	"loomi2.0/prompts"
+)
+
+// deliverableParser 把单个标签内的文本解析为结构化交付物
+type deliverableParser func(content string) (*core.Deliverable, error)
+
+// WritingAction 写作类行动执行器：产出结构化交付物并以笔记形式保存
+type WritingAction struct {
+	name        string
+	description string
+	prompt      string
+	maxItems    int
+	parse       deliverableParser
+}
+
+// NewXHSPostAction 创建小红书帖子写作行动
+func NewXHSPostAction() *WritingAction {
+	return &WritingAction{
+		name:        core.DeliverableXHSPost,
+		description: "写小红书帖子（1～3篇）",
+		prompt:      prompts.XHSPostPrompt,
+		maxItems:    3,
+		parse:       parseXHSPost,
+	}
+}
+
+// NewWechatArticleAction 创建公众号文章写作行动
+func NewWechatArticleAction() *WritingAction {
+	return &WritingAction{
+		name:        core.DeliverableWechatArticle,
+		description: "写公众号文章",
+		prompt:      prompts.WechatArticlePrompt,
+		maxItems:    1,
+		parse:       parseWechatArticle,
+	}
+}
+
+// NewTiktokScriptAction 创建抖音口播稿写作行动
+func NewTiktokScriptAction() *WritingAction {
+	return &WritingAction{
+		name:        core.DeliverableTiktokScript,
+		description: "写抖音口播稿（1～3篇）",
+		prompt:      prompts.TiktokScriptPrompt,
+		maxItems:    3,
+		parse:       parseTiktokScript,
+	}
+}
+
+// Name 行动名称
+func (a *WritingAction) Name() string {
+	return a.name
+}
+
+// Description 行动描述
+func (a *WritingAction) Description() string {
+	return a.description
+}
+
+// Execute 执行写作行动
+func (a *WritingAction) Execute(ctx context.Context, req ActionRequest) (*ActionResult, error) {
+	modelManager := models.GetModelManager()
+	if modelManager == nil {
+		return nil, fmt.Errorf("模型管理器未初始化")
+	}
+
+	output, err := modelManager.CallCurrentModel(ctx, a.prompt, buildActionUserPrompt(req), nil)
+	if err != nil {
+		return nil, fmt.Errorf("AI 模型调用失败: %w", err)
+	}
+
+	return a.parseOutput(output)
+}
+
+// parseOutput 把模型输出解析为交付物笔记；单条解析失败时保留其余条目，失败的条目写入观察
+func (a *WritingAction) parseOutput(output string) (*ActionResult, error) {
+	tagged := ParseTaggedNotes(output, a.name)
+	if len(tagged) == 0 {
+		return nil, fmt.Errorf("%s 输出中没有找到 <%s数字> 标签", a.name, a.name)
+	}
+	if len(tagged) > a.maxItems {
+		tagged = tagged[:a.maxItems]
+	}
+
+	result := &ActionResult{Raw: output}
+	for _, note := range tagged {
+		deliverable, err := a.parse(note.Content)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("解析 <%s%d> 失败: %v", a.name, note.Index, err))
+			continue
+		}
+		note.Content = deliverable.Render()
+		note.Deliverable = deliverable
+		result.Notes = append(result.Notes, note)
+	}
+	if len(result.Notes) == 0 {
+		return nil, fmt.Errorf("%s 的输出都无法解析: %s", a.name, strings.Join(result.Problems, "；"))
+	}
+
+	return result, nil
+}
+
+// extractTag 提取第一个 <tag>...</tag> 的内容
+func extractTag(content, tag string) string {
+	values := extractAllTags(content, tag)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// extractAllTags 提取所有 <tag>...</tag> 的内容
+func extractAllTags(content, tag string) []string {
+	pattern := regexp.MustCompile(`(?s)<` + regexp.QuoteMeta(tag) + `>(.*?)</` + regexp.QuoteMeta(tag) + `>`)
+	var values []string
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		values = append(values, strings.TrimSpace(match[1]))
+	}
+	return values
+}
+
+// parseHashtags 解析以空格、逗号分隔的话题标签，去掉前缀 #
+func parseHashtags(content string) []string {
+	fields := strings.FieldsFunc(content, func(r rune) bool {
+		return r == ' ' || r == '\n' || r == ',' || r == '，' || r == '、' || r == '\t'
+	})
+	var hashtags []string
+	for _, field := range fields {
+		tag := strings.Trim(field, "#")
+		if tag != "" {
+			hashtags = append(hashtags, tag)
+		}
+	}
+	return hashtags
+}
+
+func parseXHSPost(content string) (*core.Deliverable, error) {
+	post := &core.XHSPost{
+		Title:    extractTag(content, "title"),
+		Body:     extractTag(content, "body"),
+		Hashtags: parseHashtags(extractTag(content, "hashtags")),
+	}
+	if post.Title == "" || post.Body == "" {
+		return nil, fmt.Errorf("缺少 title 或 body")
+	}
+	return &core.Deliverable{Kind: core.DeliverableXHSPost, XHSPost: post}, nil
+}
+
+func parseWechatArticle(content string) (*core.Deliverable, error) {
+	article := &core.WechatArticle{
+		Headline: extractTag(content, "headline"),
+	}
+	for _, section := range extractAllTags(content, "section") {
+		article.Sections = append(article.Sections, core.ArticleSection{
+			Heading: extractTag(section, "heading"),
+			Content: extractTag(section, "content"),
+		})
+	}
+	if article.Headline == "" || len(article.Sections) == 0 {
+		return nil, fmt.Errorf("缺少 headline 或 section")
+	}
+	return &core.Deliverable{Kind: core.DeliverableWechatArticle, WechatArticle: article}, nil
+}
+
+func parseTiktokScript(content string) (*core.Deliverable, error) {
+	script := &core.TiktokScript{
+		Hook:    extractTag(content, "hook"),
+		Body:    extractTag(content, "body"),
+		Closing: extractTag(content, "closing"),
+	}
+	if script.Hook == "" || script.Body == "" {
+		return nil, fmt.Errorf("缺少 hook 或 body")
+	}
+	return &core.Deliverable{Kind: core.DeliverableTiktokScript, TiktokScript: script}, nil
+}
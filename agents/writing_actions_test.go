@@ -0,0 +1,36 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWritingActionKeepsParsedItems(t *testing.T) {
+	output := `<xhs_post1>
+<title>入职第二年，我靠这三招不再加班</title>
+<body>每天早上先写下今天最重要的三件事。</body>
+<hashtags>#职场干货</hashtags>
+</xhs_post1>
+<xhs_post2>
+<body>忘了写标题</body>
+</xhs_post2>
+<xhs_post3>
+<title>下班后的一小时</title>
+<body>把手机放远一点。</body>
+</xhs_post3>`
+
+	result, err := NewXHSPostAction().parseOutput(output)
+	if err != nil {
+		t.Fatalf("部分条目可以解析时不应返回错误: %v", err)
+	}
+	if len(result.Notes) != 2 || result.Notes[0].Index != 1 || result.Notes[1].Index != 3 {
+		t.Errorf("应当保留第 1、3 篇，实际 %+v", result.Notes)
+	}
+	if len(result.Problems) != 1 || !strings.Contains(result.Problems[0], "<xhs_post2>") {
+		t.Errorf("应当报告第 2 篇解析失败，实际 %v", result.Problems)
+	}
+
+	if _, err := NewXHSPostAction().parseOutput("<xhs_post1><body>只有正文</body></xhs_post1>"); err == nil {
+		t.Error("所有条目都无法解析时应当返回错误")
+	}
+}
@@ -0,0 +1,236 @@
+package cassette
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version 磁带文件格式版本
+const Version = 1
+
+// 交互类型
+const (
+	KindModel = "model"
+	KindTool  = "tool"
+)
+
+// ErrNotRecorded 回放时磁带中没有匹配的请求
+var ErrNotRecorded = errors.New("磁带中没有匹配的请求")
+
+// Message 模型请求中的一条消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Interaction 一次录制的请求和响应
+type Interaction struct {
+	Kind       string          `json:"kind"`
+	Name       string          `json:"name"`   // 模型提供商或工具名称
+	Method     string          `json:"method"` // generate、stream、execute、search
+	Key        string          `json:"key"`
+	Messages   []Message       `json:"messages,omitempty"` // 模型请求
+	Input      string          `json:"input,omitempty"`    // 工具请求
+	Output     string          `json:"output,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"` // 结构化响应，例如搜索结果
+	Error      string          `json:"error,omitempty"`
+	ErrorInfo  *ErrorInfo      `json:"error_info,omitempty"` // 模型提供商错误的类别等信息，回放时据此重建错误
+	Usage      *Usage          `json:"usage,omitempty"`      // 模型调用的用量，回放时照此记账
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// ErrorInfo 录制的模型提供商错误：类别、状态码、Retry-After 和原始错误信息
+type ErrorInfo struct {
+	Provider   string        `json:"provider"`
+	Kind       string        `json:"kind"`
+	StatusCode int           `json:"status_code,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Cause      string        `json:"cause"`
+}
+
+// Usage 录制的模型调用用量
+type Usage struct {
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	ThinkingTokens int     `json:"thinking_tokens,omitempty"`
+	CachedTokens   int     `json:"cached_tokens,omitempty"`
+	Cost           float64 `json:"cost"`
+	Estimated      bool    `json:"estimated,omitempty"`
+}
+
+// Cassette 录制/回放磁带：录制时每条交互追加后立即写盘，回放时同一请求按录制顺序返回
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+
+	path   string
+	mu     sync.Mutex
+	served map[string]int
+}
+
+// New 创建用于录制的空磁带，文件已存在时会被覆盖
+func New(path string) (*Cassette, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建磁带目录失败: %v", err)
+	}
+	c := &Cassette{Version: Version, Interactions: []*Interaction{}, path: path}
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load 加载用于回放的磁带
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取磁带失败: %v", err)
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("解析磁带 %s 失败: %v", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("不支持的磁带版本: %d", c.Version)
+	}
+	c.path = path
+	return c, nil
+}
+
+// Path 磁带文件路径
+func (c *Cassette) Path() string {
+	return c.path
+}
+
+// Len 已录制的交互数
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Interactions)
+}
+
+// Names 磁带中出现过的某类交互的名称，按首次出现的顺序
+func (c *Cassette) Names(kind string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, in := range c.Interactions {
+		if in.Kind == kind && !seen[in.Name] {
+			seen[in.Name] = true
+			names = append(names, in.Name)
+		}
+	}
+	return names
+}
+
+// Record 追加一条交互并写盘
+func (c *Cassette) Record(in *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if in.RecordedAt.IsZero() {
+		in.RecordedAt = time.Now()
+	}
+	c.Interactions = append(c.Interactions, in)
+	return c.save()
+}
+
+// Next 按录制顺序取出与 key 匹配的下一条交互，录制的次数用完后重复最后一条
+func (c *Cassette) Next(key string) (*Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.served == nil {
+		c.served = make(map[string]int)
+	}
+
+	var matched []*Interaction
+	for _, in := range c.Interactions {
+		if in.Key == key {
+			matched = append(matched, in)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%w (key %s)", ErrNotRecorded, key)
+	}
+
+	index := c.served[key]
+	c.served[key]++
+	if index >= len(matched) {
+		index = len(matched) - 1
+	}
+	return matched[index], nil
+}
+
+// save 原子地写入磁带文件，调用方需持有锁
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化磁带失败: %v", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入磁带失败: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("写入磁带失败: %v", err)
+	}
+	return nil
+}
+
+// volatilePatterns 请求中每次运行都会变化的内容，计算 key 前替换掉
+var volatilePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{8}-\d{6}-[0-9a-f]{6}`), "<session>"},
+	{regexp.MustCompile(`(\d{4}-)?\d{2}-\d{2}[ T]\d{2}:\d{2}(:\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}(:\d{2})?\b`), "<time>"},
+}
+
+// Normalize 规范化请求文本：统一换行、去掉行尾空白，并替换时间戳、会话ID等每次运行都会变化的内容
+func Normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = strings.TrimSpace(strings.Join(lines, "\n"))
+	for _, v := range volatilePatterns {
+		text = v.pattern.ReplaceAllString(text, v.replacement)
+	}
+	return text
+}
+
+// Key 由交互类型和规范化后的请求内容计算 key
+func Key(kind string, parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(kind))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(Normalize(part)))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// ModelKey 模型请求的 key；不包含提供商名称，录制的会话可以用任意模型回放
+func ModelKey(messages []Message) string {
+	parts := make([]string, 0, len(messages)*2)
+	for _, msg := range messages {
+		parts = append(parts, msg.Role, msg.Content)
+	}
+	return Key(KindModel, parts...)
+}
+
+// ToolKey 工具请求的 key
+func ToolKey(name, method, input string) string {
+	return Key(KindTool, name, method, input)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"loomi2.0/core"
+	"loomi2.0/export"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "导出会话的交付内容",
+	Long:  "把会话的交付内容、支撑笔记和搜索来源导出为 Markdown、JSON 或自包含的 HTML 报告",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runExport,
+	// 错误由 main 统一输出，运行时错误不需要打印用法
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&dataDir, "data-dir", "", "会话存储目录（默认使用配置中的 data_dir）")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "md", "导出格式: md、json、html")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "输出文件（默认 loomi-<id>.<格式>）")
+}
+
+func ExportCmd() *cobra.Command {
+	return exportCmd
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	format, err := export.ParseFormat(exportFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := core.NewFileSessionStore(sessionDir())
+	if err != nil {
+		return err
+	}
+	session, err := store.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	return exportSession(session, format, exportOutput)
+}
+
+// exportSession 导出会话，output 为空时使用默认文件名
+func exportSession(session *core.SessionData, format export.Format, output string) error {
+	if output == "" {
+		output = export.DefaultFileName(session.ID, format)
+	}
+	if err := export.WriteFile(session, format, output); err != nil {
+		return err
+	}
+	color.Green("✅ 已导出到 %s", output)
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"loomi2.0/core"
+	"loomi2.0/export"
+)
+
+func TestRunExport(t *testing.T) {
+	dir := t.TempDir()
+	store, err := core.NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("创建会话存储失败: %v", err)
+	}
+	session := &core.SessionData{
+		ID:        "20260308-093000-export",
+		Title:     "导出测试",
+		CreatedAt: time.Date(2026, 3, 8, 9, 30, 0, 0, time.UTC),
+		Workspace: core.WorkspaceSnapshot{
+			Notes: []core.Note{
+				{ID: "insight1", Type: "insight", Index: 1, Round: 1, Content: "怕麻烦胜过怕晒黑"},
+				{ID: "xhs_post1", Type: "xhs_post", Index: 1, Round: 2, Deliverable: &core.Deliverable{
+					Kind:    core.DeliverableXHSPost,
+					XHSPost: &core.XHSPost{Title: "通勤党的防晒只要一步", Body: "早上出门前抹一层。"},
+				}},
+			},
+			Tactics: []core.Tactic{{Round: 1, Action: "insight"}, {Round: 2, Action: "xhs_post"}},
+			Rounds:  2,
+		},
+	}
+	if err := store.Save(session); err != nil {
+		t.Fatalf("保存会话失败: %v", err)
+	}
+
+	defer func(dir, format, output string) {
+		dataDir, exportFormat, exportOutput = dir, format, output
+	}(dataDir, exportFormat, exportOutput)
+	dataDir = dir
+	exportFormat = "html"
+	exportOutput = filepath.Join(dir, "report.html")
+
+	if err := runExport(exportCmd, []string{session.ID}); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	got, err := os.ReadFile(exportOutput)
+	if err != nil {
+		t.Fatalf("读取导出文件失败: %v", err)
+	}
+	// 从存储中读回的会话与原会话导出的报告一致
+	want, err := export.BuildReport(session).HTML()
+	if err != nil {
+		t.Fatalf("渲染报告失败: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("导出文件内容错误:\n%s", got)
+	}
+
+	exportFormat = "pdf"
+	if err := runExport(exportCmd, []string{session.ID}); err == nil || !strings.Contains(err.Error(), "不支持的导出格式") {
+		t.Errorf("不支持的格式应当报错，实际 %v", err)
+	}
+	exportFormat = "md"
+	if err := runExport(exportCmd, []string{"20260101-000000-missing"}); err == nil {
+		t.Error("不存在的会话应当报错")
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"loomi2.0/core"
+)
+
+var forkRound int
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "管理保存在磁盘上的会话",
+	Long:  "列出、fork 和删除保存在会话目录中的会话，使用 start --resume <id> 继续会话",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有会话",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionsList,
+}
+
+var sessionsForkCmd = &cobra.Command{
+	Use:   "fork <id>",
+	Short: "从会话的某一轮复制出新会话",
+	Long:  "保留指定轮次及之前的笔记、战术备忘和对话，复制出一个新会话，用于从同一研究阶段探索不同的创作方向",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionsFork,
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "删除会话",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSessionsDelete,
+}
+
+func init() {
+	sessionsCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "会话存储目录（默认使用配置中的 data_dir）")
+	sessionsForkCmd.Flags().IntVar(&forkRound, "at-round", 0, "fork 的轮次（保留该轮及之前的结果）")
+	sessionsForkCmd.MarkFlagRequired("at-round")
+
+	// 错误由 main 统一输出，运行时错误不需要打印用法
+	for _, c := range []*cobra.Command{sessionsListCmd, sessionsForkCmd, sessionsDeleteCmd} {
+		c.SilenceUsage = true
+		c.SilenceErrors = true
+		sessionsCmd.AddCommand(c)
+	}
+}
+
+func SessionsCmd() *cobra.Command {
+	return sessionsCmd
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) error {
+	store, err := core.NewFileSessionStore(sessionDir())
+	if err != nil {
+		return err
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(metas) == 0 {
+		color.Yellow("📭 %s 中没有会话", store.Dir())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t创建时间\t标题\t消息数\t费用")
+	for _, meta := range metas {
+		title := meta.Title
+		if meta.ParentID != "" {
+			title = fmt.Sprintf("%s (fork: %s)", title, meta.ParentID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t$%.4f\n",
+			meta.ID, meta.CreatedAt.Format("2006-01-02 15:04"), title, meta.MessageCount, meta.Cost)
+	}
+	return w.Flush()
+}
+
+func runSessionsFork(cmd *cobra.Command, args []string) error {
+	store, err := core.NewFileSessionStore(sessionDir())
+	if err != nil {
+		return err
+	}
+
+	session, err := store.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	forked, err := session.Fork(forkRound)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(forked); err != nil {
+		return err
+	}
+
+	color.Green("✅ 已从 %s 的 Round%d fork 出新会话 %s（%d 条笔记）", session.ID, forkRound, forked.ID, len(forked.Workspace.Notes))
+	color.Cyan("使用 start --resume %s 继续", forked.ID)
+	return nil
+}
+
+func runSessionsDelete(cmd *cobra.Command, args []string) error {
+	store, err := core.NewFileSessionStore(sessionDir())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range args {
+		if err := store.Delete(id); err != nil {
+			return err
+		}
+		color.Green("🗑️  已删除会话 %s", id)
+	}
+	return nil
+}
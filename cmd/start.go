@@ -3,7 +3,9 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -11,8 +13,12 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"loomi2.0/agents"
+	"loomi2.0/cassette"
+	"loomi2.0/config"
 	"loomi2.0/core"
+	"loomi2.0/export"
 	"loomi2.0/models"
+	"loomi2.0/tools"
 	"loomi2.0/utils"
 )
 
@@ -23,6 +29,37 @@ var startCmd = &cobra.Command{
 	Run:   runStart,
 }
 
+var (
+	approvalMode  bool
+	noStream      bool
+	dataDir       string
+	resumeSession string
+	fakeMode      bool
+	fakeScript    string
+	recordFile    string
+	replayFile    string
+)
+
+func init() {
+	startCmd.Flags().BoolVar(&approvalMode, "approval", false, "写作行动执行前暂停，等待审批")
+	startCmd.Flags().BoolVar(&noStream, "no-stream", false, "关闭流式输出，等完整回复生成后再显示")
+	startCmd.Flags().StringVar(&dataDir, "data-dir", "", "会话存储目录（默认使用配置中的 data_dir）")
+	startCmd.Flags().StringVar(&resumeSession, "resume", "", "恢复指定ID的会话")
+	startCmd.Flags().BoolVar(&fakeMode, "fake", false, "使用按脚本应答的 fake 模型离线运行，不需要 API 密钥")
+	startCmd.Flags().StringVar(&fakeScript, "fake-script", "", "fake 模型的应答脚本（YAML），指定时自动启用 --fake")
+	startCmd.Flags().StringVar(&recordFile, "record", "", "把模型和搜索调用录制到磁带文件")
+	startCmd.Flags().StringVar(&replayFile, "replay", "", "从磁带文件回放模型和搜索调用，不访问网络")
+	startCmd.MarkFlagsMutuallyExclusive("record", "replay")
+}
+
+// sessionDir 会话存储目录：命令行参数优先，其次是配置
+func sessionDir() string {
+	if dataDir != "" {
+		return dataDir
+	}
+	return config.GetConfig().DataDir
+}
+
 func StartCmd() *cobra.Command {
 	return startCmd
 }
@@ -43,6 +80,12 @@ func runStart(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if approvalMode {
+		agents.GetOrchestrator().SetApprovalMode(true)
+		color.Green("✅ 已开启审批模式，写作行动执行前会等待您的确认")
+	}
+	setStreaming(!noStream)
+
 	// 启动交互循环
 	startInteractiveLoop()
 }
@@ -55,11 +98,35 @@ func showStartupInfo() {
 
 func initSystem() error {
 	color.Green("🔧 初始化系统组件...")
+
+	// 校验配置，密钥和地址有问题时在这里给出完整的错误列表
+	cfg := config.GetConfig()
+	if fakeMode || fakeScript != "" {
+		cfg.UseFake(fakeScript)
+		color.Yellow("🧪 离线模式：使用 fake 模型，不会调用真实的 API")
+	}
+	if replayFile != "" {
+		cfg.UseReplay(replayFile)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Path() != "" {
+		color.Green("✅ 已加载配置 %s", cfg.Path())
+	}
 	
 	// 初始化模型管理器
 	if err := models.InitModelManager(); err != nil {
 		return fmt.Errorf("模型管理器初始化失败: %v", err)
 	}
+	retry := models.GetModelManager().RetryPolicy()
+	retry.OnRetry = func(attempt int, delay time.Duration, err error) {
+		color.HiBlack("⏳ %v，%s 后第 %d 次尝试", err, delay.Round(100*time.Millisecond), attempt)
+	}
+	models.GetModelManager().SetRetryPolicy(retry)
+	models.GetModelManager().SetFallbackHandler(func(from, to string, err error) {
+		color.Yellow("↪️  %s 不可用（%v），改用 %s", from, err, to)
+	})
 	color.Green("✅ 模型管理器初始化完成")
 
 	// 初始化工作空间
@@ -74,6 +141,36 @@ func initSystem() error {
 	}
 	color.Green("✅ 对话管理器初始化完成")
 
+	// 初始化会话存储，之后的每次变更都会保存到磁盘
+	store, err := core.NewFileSessionStore(sessionDir())
+	if err != nil {
+		return fmt.Errorf("会话存储初始化失败: %v", err)
+	}
+	if err := core.InitSessionStore(store); err != nil {
+		return fmt.Errorf("会话存储初始化失败: %v", err)
+	}
+	core.SetSessionCostFunc(func() float64 {
+		return models.GetSessionStats().TotalCost
+	})
+	if resumeSession != "" {
+		if err := core.ResumeSession(resumeSession); err != nil {
+			return fmt.Errorf("恢复会话失败: %v", err)
+		}
+		color.Green("✅ 已恢复会话 %s", resumeSession)
+	} else {
+		color.Green("✅ 会话存储初始化完成")
+	}
+
+	// 初始化工具管理器
+	if err := tools.InitToolManager(); err != nil {
+		return fmt.Errorf("工具管理器初始化失败: %v", err)
+	}
+	color.Green("✅ 工具管理器初始化完成")
+
+	if err := setupCassette(); err != nil {
+		return err
+	}
+
 	// 初始化智能体
 	if err := agents.InitAgents(); err != nil {
 		return fmt.Errorf("智能体初始化失败: %v", err)
@@ -83,12 +180,48 @@ func initSystem() error {
 	return nil
 }
 
+// setupCassette 按 --record/--replay 包装模型和搜索工具
+func setupCassette() error {
+	switch {
+	case recordFile != "":
+		c, err := cassette.New(recordFile)
+		if err != nil {
+			return fmt.Errorf("创建磁带失败: %v", err)
+		}
+		models.GetModelManager().WrapProviders(func(provider models.ModelProvider) models.ModelProvider {
+			return models.NewRecordingProvider(provider, c)
+		})
+		tools.GetToolManager().WrapTools(func(tool tools.Tool) tools.Tool {
+			return tools.NewRecordingTool(tool, c)
+		})
+		color.Yellow("⏺️  正在录制模型和搜索调用到 %s", c.Path())
+	case replayFile != "":
+		c, err := cassette.Load(replayFile)
+		if err != nil {
+			return fmt.Errorf("加载磁带失败: %v", err)
+		}
+		for _, name := range c.Names(cassette.KindTool) {
+			tools.GetToolManager().RegisterTool(tools.NewReplayTool(name, c))
+		}
+		color.Yellow("⏯️  回放磁带 %s（%d 条录制），不会调用真实的 API", c.Path(), c.Len())
+	}
+	return nil
+}
+
 func selectModel() error {
 	availableModels := models.GetAvailableModels()
 	if len(availableModels) == 0 {
 		return fmt.Errorf("没有可用的模型")
 	}
 
+	// 配置了默认模型时不再询问
+	if defaultModel := config.GetConfig().DefaultModel; defaultModel != "" {
+		if displayName, exists := availableModels[defaultModel]; exists {
+			color.Green("✅ 使用默认模型: %s", displayName)
+			return models.SetCurrentModel(defaultModel)
+		}
+	}
+
 	color.Cyan("\n🤖 选择您要使用的模型：")
 	color.Cyan("")
 
@@ -139,8 +272,14 @@ func selectModel() error {
 
 func startInteractiveLoop() {
 	color.Cyan("\n🎯 系统已启动，输入 'help' 查看帮助，输入 'quit' 退出")
+	color.Cyan("📁 会话ID: %s（使用 start --resume %s 继续）", core.GetConversationManager().GetSessionID(), core.GetConversationManager().GetSessionID())
 	color.Cyan(strings.Repeat("=", 60))
 
+	// 后台任务结束时输出结果，等待审批时展示提议
+	go watchOrchestratorJobs()
+	go watchApprovalRequests()
+	go watchStreamChunks()
+
 	reader := bufio.NewReader(os.Stdin)
 	
 	for {
@@ -162,14 +301,57 @@ func startInteractiveLoop() {
 			continue
 		}
 
+		// 有等待审批的提议时，输入作为审批结果
+		if handleApprovalInput(input) {
+			continue
+		}
+
 		// 处理用户输入
 		if err := handleUserInput(input); err != nil {
-			color.Red("❌ 处理用户输入失败: %v", err)
+			var budgetErr *models.BudgetError
+			if errors.As(err, &budgetErr) {
+				color.Red("💸 %v，已停止调用模型", budgetErr)
+				color.Yellow("   已生成的内容仍保留在会话中，可以用 export 导出；调整 budget 配置后重新启动可以继续")
+			} else if !printProviderError("❌ 模型调用失败", err) {
+				color.Red("❌ 处理用户输入失败: %v", err)
+			}
 		}
 	}
 }
 
+// providerErrorHints 各类模型调用错误的处理建议
+var providerErrorHints = map[models.ErrorKind]string{
+	models.ErrorKindRateLimited:     "已按 retry 配置重试仍被限流，请稍后再试",
+	models.ErrorKindAuth:            "请检查该提供商的 API Key 是否有效、账户额度是否充足",
+	models.ErrorKindContextTooLong:  "输入超出模型的上下文长度，可以退出后重新运行 start 开始新会话、用 sessions fork <id> --at-round <轮次> 从较早的轮次继续，或换用上下文更长的模型",
+	models.ErrorKindContentFiltered: "内容被提供商的安全策略拦截，请调整表述后重试",
+	models.ErrorKindServer:          "提供商服务暂时不可用，已按 retry 配置重试，请稍后再试或换用其他模型",
+	models.ErrorKindTimeout:         "请求超时，请检查网络后重试",
+}
+
+// printProviderError 错误来自模型提供商时打印错误和按类别的处理建议，不是时返回 false
+func printProviderError(prefix string, err error) bool {
+	var providerErr *models.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	color.Red("%s: %v", prefix, providerErr)
+	if hint, ok := providerErrorHints[providerErr.Kind]; ok {
+		color.Yellow("   %s", hint)
+	}
+	return true
+}
+
 func handleSpecialCommands(input string) bool {
+	if fields := strings.Fields(input); len(fields) > 0 && strings.ToLower(fields[0]) == "export" {
+		exportCurrentSession(fields[1:])
+		return true
+	}
+	if fields := strings.Fields(input); len(fields) == 2 && strings.ToLower(fields[0]) == "status" {
+		showUsage(models.UsageDimension(strings.ToLower(fields[1])))
+		return true
+	}
+
 	switch strings.ToLower(input) {
 	case "quit", "exit", "q":
 		color.Yellow("👋 再见！")
@@ -184,8 +366,27 @@ func handleSpecialCommands(input string) bool {
 	case "clear":
 		utils.ClearScreen()
 		return true
-	case "orchestrator", "orch":
-		startOrchestrator()
+	case "orchestrator", "orch", "job":
+		showOrchestratorJob()
+		return true
+	case "cancel":
+		cancelOrchestratorJob()
+		return true
+	case "approval on":
+		agents.GetOrchestrator().SetApprovalMode(true)
+		color.Green("✅ 已开启审批模式")
+		return true
+	case "approval off":
+		agents.GetOrchestrator().SetApprovalMode(false)
+		color.Green("✅ 已关闭审批模式")
+		return true
+	case "stream on":
+		setStreaming(true)
+		color.Green("✅ 已开启流式输出")
+		return true
+	case "stream off":
+		setStreaming(false)
+		color.Green("✅ 已关闭流式输出")
 		return true
 	}
 	return false
@@ -195,10 +396,14 @@ func handleUserInput(input string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if streamMode {
+		return streamUserInput(ctx, input)
+	}
+
 	// 使用eino框架处理用户输入
 	response, err := agents.ProcessUserInput(ctx, input)
 	if err != nil {
-		return fmt.Errorf("处理用户输入失败: %v", err)
+		return fmt.Errorf("处理用户输入失败: %w", err)
 	}
 
 	// 显示响应
@@ -207,13 +412,90 @@ func handleUserInput(input string) error {
 	return nil
 }
 
+// streamMode 是否流式显示回复
+var streamMode bool
+
+// setStreaming 同时设置门房回复和编排任务的流式输出
+func setStreaming(enabled bool) {
+	streamMode = enabled
+	agents.GetOrchestrator().SetStreaming(enabled)
+}
+
+// streamUserInput 流式处理用户输入，回复逐段显示，结束后显示本轮的调用统计
+func streamUserInput(ctx context.Context, input string) error {
+	before := models.GetSessionStats()
+
+	stream, err := agents.ProcessUserInputStream(ctx, input)
+	if err != nil {
+		return fmt.Errorf("处理用户输入失败: %w", err)
+	}
+	defer stream.Close()
+
+	green := color.New(color.FgGreen)
+	green.Print("\n🤖 Loomi: ")
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println()
+			return fmt.Errorf("处理用户输入失败: %w", err)
+		}
+		green.Print(chunk.Content)
+	}
+	fmt.Println()
+
+	showTurnStats(before, models.GetSessionStats())
+	return nil
+}
+
+// showTurnStats 显示两次统计之间的调用次数、token 和费用，以及由后备提供商应答的调用
+func showTurnStats(before, after models.SessionStats) {
+	calls := after.TotalCalls - before.TotalCalls
+	if calls == 0 {
+		return
+	}
+	color.HiBlack("   %d 次调用 · 输入 %d / 输出 %d tokens · $%.4f（累计 $%.4f）",
+		calls,
+		after.TotalInputTokens-before.TotalInputTokens,
+		after.TotalOutputTokens-before.TotalOutputTokens,
+		after.TotalCost-before.TotalCost,
+		after.TotalCost)
+
+	// 当前模型不可用时提示实际应答的提供商
+	records := models.GetUsageRecords()
+	if before.TotalCalls > len(records) {
+		return
+	}
+	answered := make(map[string]bool)
+	for _, record := range records[before.TotalCalls:] {
+		key := record.FallbackFrom + " -> " + record.Provider
+		if record.FallbackFrom == "" || answered[key] {
+			continue
+		}
+		answered[key] = true
+		color.HiBlack("   %s 不可用，由 %s 应答", record.FallbackFrom, record.Provider)
+	}
+}
+
 func showHelp() {
 	helpText := `
 📚 可用命令:
   help, h          - 显示此帮助信息
-  status           - 显示系统状态
+  status           - 显示系统状态和按模型、智能体、行动拆分的用量
+  status <维度>    - 按 provider|model|agent|action|session 拆分用量
   clear            - 清屏
-  orchestrator     - 启动任务编排器
+  orchestrator     - 查看后台任务进度
+  cancel           - 取消正在运行的任务
+  approval on|off  - 开启/关闭写作行动审批模式
+  stream on|off    - 开启/关闭流式输出
+  export [md|json|html] [文件] - 导出当前会话的交付内容
+
+✋ 审批模式下有等待审批的提议时:
+  y                - 按原指令执行
+  e <新指令>       - 修改指令后执行
+  r <新方向>       - 不执行，让编排器换个方向
   quit, exit, q    - 退出系统
 
 💡 提示:
@@ -235,18 +517,218 @@ func showStatus() {
 	
 	currentModel := models.GetCurrentModelName()
 	color.Cyan("  当前模型: %s", currentModel)
+
+	budgetCtx := context.Background()
+	if job := agents.GetOrchestratorJob(); job != nil {
+		color.Cyan("  编排任务: %s", job)
+		budgetCtx = models.WithBudget(budgetCtx, job.Budget())
+	}
+	for _, status := range models.GetBudgetStatuses(budgetCtx) {
+		color.Cyan("  %s预算: %s", status.ScopeName(), status)
+	}
+	for _, status := range models.GetCircuitStatuses() {
+		if status.State != models.CircuitClosed || status.Failures > 0 {
+			color.Cyan("  熔断器: %s", status)
+		}
+	}
+
+	if stats.TotalCalls > 0 {
+		for _, dim := range []models.UsageDimension{models.UsageByModel, models.UsageByAgent, models.UsageByAction} {
+			showUsageTable(dim)
+		}
+	}
 }
 
-func startOrchestrator() {
-	color.Yellow("🚀 启动任务编排器...")
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+// usageDimensionNames 用量维度的显示名称
+var usageDimensionNames = map[models.UsageDimension]string{
+	models.UsageBySession:  "会话",
+	models.UsageByAgent:    "智能体",
+	models.UsageByAction:   "行动",
+	models.UsageByProvider: "提供商",
+	models.UsageByModel:    "模型",
+}
 
-	if err := agents.StartOrchestrator(ctx); err != nil {
-		color.Red("❌ 启动编排器失败: %v", err)
+// showUsage 显示一个维度的用量拆分
+func showUsage(dim models.UsageDimension) {
+	if _, ok := usageDimensionNames[dim]; !ok {
+		color.Red("❌ 不支持的维度: %s，可选 provider|model|agent|action|session", dim)
+		return
+	}
+	if models.GetSessionStats().TotalCalls == 0 {
+		color.Yellow("📭 暂无模型调用")
+		return
+	}
+	showUsageTable(dim)
+}
+
+// showUsageTable 按维度显示调用次数、token、平均耗时和费用，按费用从高到低排列
+func showUsageTable(dim models.UsageDimension) {
+	color.Cyan("\n💰 按%s:", usageDimensionNames[dim])
+	for _, summary := range models.GetUsageSummary(dim) {
+		color.Cyan("  %-24s %4d 次  输入 %7d  输出 %6d  思考 %5d  缓存 %6d  平均 %6s  $%.4f",
+			summary.Key,
+			summary.Calls,
+			summary.InputTokens,
+			summary.OutputTokens,
+			summary.ThinkingTokens,
+			summary.CachedTokens,
+			summary.AverageLatency().Round(100*time.Millisecond),
+			summary.Cost)
+	}
+}
+
+func showOrchestratorJob() {
+	job := agents.GetOrchestratorJob()
+	if job == nil {
+		color.Yellow("📭 暂无编排任务")
 		return
 	}
 
-	color.Green("✅ 编排器已启动，可以开始任务编排")
-} 
\ No newline at end of file
+	color.Cyan("\n📋 编排任务: %s", job)
+	color.Cyan("  开始时间: %s", job.StartedAt.Format("15:04:05"))
+	if budget := job.Budget(); budget != nil && budget.Status().Limits.Limited() {
+		color.Cyan("  任务预算: %s", budget.Status())
+	}
+	if !job.IsRunning() {
+		color.Cyan("  结束时间: %s", job.FinishedAt().Format("15:04:05"))
+	}
+	for _, tactic := range core.GetWorkspace().GetTactics() {
+		color.Cyan("  %s", tactic)
+	}
+	if request := agents.GetOrchestrator().PendingApproval(); request != nil {
+		showApprovalRequest(request)
+	}
+}
+
+func cancelOrchestratorJob() {
+	if !agents.IsOrchestratorRunning() {
+		color.Yellow("📭 没有正在运行的任务")
+		return
+	}
+	agents.StopOrchestrator()
+	color.Yellow("🛑 已请求取消任务，当前步骤结束后停止")
+}
+
+// watchStreamChunks 显示编排任务执行行动时的流式输出
+func watchStreamChunks() {
+	orchestrator := agents.GetOrchestrator()
+	if orchestrator == nil {
+		return
+	}
+	output := color.New(color.FgHiBlack)
+	started := false
+	for chunk := range orchestrator.StreamChunks() {
+		if chunk.Done {
+			if started {
+				fmt.Println()
+				started = false
+			}
+			continue
+		}
+		if !started {
+			color.Cyan("\n✍️  任务 %s Round%d %s:", chunk.JobID, chunk.Round, chunk.Action)
+			started = true
+		}
+		output.Print(chunk.Content)
+	}
+}
+
+// watchOrchestratorJobs 监听后台编排任务，结束时输出结果
+func watchOrchestratorJobs() {
+	orchestrator := agents.GetOrchestrator()
+	if orchestrator == nil {
+		return
+	}
+	for job := range orchestrator.JobUpdates() {
+		result, err := job.Result()
+		switch job.Status() {
+		case agents.JobStatusCompleted:
+			color.Green("\n✅ 任务 %s 完成\n🤖 Loomi: %s", job.ID, result)
+		case agents.JobStatusCancelled:
+			color.Yellow("\n🛑 任务 %s 已取消", job.ID)
+		default:
+			var budgetErr *models.BudgetError
+			if errors.As(err, &budgetErr) {
+				color.Red("\n💸 任务 %s 已停止: %v", job.ID, budgetErr)
+				color.Yellow("   已完成的笔记保留在工作空间中，可以用 orchestrator 查看或 export 导出")
+			} else if !printProviderError(fmt.Sprintf("\n❌ 任务 %s 失败", job.ID), err) {
+				color.Red("\n❌ 任务 %s 失败: %v", job.ID, err)
+			}
+		}
+		color.Cyan("\n💬 请输入您的消息: ")
+	}
+}
+
+// watchApprovalRequests 监听编排器的审批请求并展示提议
+func watchApprovalRequests() {
+	orchestrator := agents.GetOrchestrator()
+	if orchestrator == nil {
+		return
+	}
+	for request := range orchestrator.ApprovalRequests() {
+		showApprovalRequest(request)
+	}
+}
+
+func showApprovalRequest(request *agents.ApprovalRequest) {
+	color.Yellow("\n✋ 任务 %s 等待审批:", request.JobID)
+	color.Yellow(request.String())
+	color.Cyan("输入 y 执行，e <新指令> 修改后执行，r <新方向> 换个方向")
+	color.Cyan("\n💬 请输入您的消息: ")
+}
+
+// handleApprovalInput 把输入解析为审批结果；没有等待审批的提议时返回 false
+func handleApprovalInput(input string) bool {
+	orchestrator := agents.GetOrchestrator()
+	if orchestrator == nil {
+		return false
+	}
+	request := orchestrator.PendingApproval()
+	if request == nil {
+		return false
+	}
+
+	command, argument, _ := strings.Cut(input, " ")
+	argument = strings.TrimSpace(argument)
+
+	var response agents.ApprovalResponse
+	switch strings.ToLower(command) {
+	case "y", "yes", "ok", "通过":
+		response = agents.ApprovalResponse{Decision: agents.ApprovalApprove}
+	case "e", "edit":
+		response = agents.ApprovalResponse{Decision: agents.ApprovalEdit, Instruction: argument}
+	case "r", "redirect":
+		response = agents.ApprovalResponse{Decision: agents.ApprovalRedirect, Feedback: argument}
+	default:
+		showApprovalRequest(request)
+		return true
+	}
+
+	if err := orchestrator.ResolveApproval(response); err != nil {
+		color.Red("❌ 审批失败: %v", err)
+		return true
+	}
+	color.Green("✅ 已提交，任务继续执行")
+	return true
+}
+
+// exportCurrentSession 处理 export [格式] [文件] 命令
+func exportCurrentSession(args []string) {
+	formatName := "md"
+	output := ""
+	if len(args) > 0 {
+		formatName = args[0]
+	}
+	if len(args) > 1 {
+		output = args[1]
+	}
+
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	if err := exportSession(core.SnapshotSession(), format, output); err != nil {
+		color.Red("❌ 导出失败: %v", err)
+	}
+}
@@ -0,0 +1,594 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"loomi2.0/core"
+)
+
+// EnvPrefix 环境变量前缀，例如 LOOMI_DEEPSEEK_API_KEY
+const EnvPrefix = "LOOMI_"
+
+// Pricing 模型价格，单位为美元/百万 token
+type Pricing struct {
+	InputPer1M  float64 `yaml:"input_per_1m"`
+	OutputPer1M float64 `yaml:"output_per_1m"`
+}
+
+// 提供商类型
+const (
+	ProviderTypeOpenAI = "openai" // OpenAI 兼容接口：DeepSeek、豆包、Qwen、Moonshot、vLLM、Ollama 等
+	ProviderTypeGemini = "gemini"
+	ProviderTypeFake   = "fake"   // 按脚本应答的假模型，用于离线演示和测试
+	ProviderTypeReplay = "replay" // 回放录制的磁带
+)
+
+// ProviderCapabilities 提供商能力开关，未设置的开关使用默认值
+type ProviderCapabilities struct {
+	Stream        *bool `yaml:"stream"`         // 是否支持流式输出，默认支持
+	SystemMessage *bool `yaml:"system_message"` // 是否支持 system 消息，不支持时并入第一条用户消息，默认支持
+	StreamUsage   *bool `yaml:"stream_usage"`   // 流式请求是否要求返回用量（stream_options.include_usage），默认要求
+}
+
+// ProviderConfig 模型提供商配置
+type ProviderConfig struct {
+	Name         string               `yaml:"name"`         // 提供商名称，用于选择模型和环境变量
+	Type         string               `yaml:"type"`         // 提供商类型: openai、gemini、fake、replay
+	DisplayName  string               `yaml:"display_name"` // 展示名称，为空时使用 name
+	BaseURL      string               `yaml:"base_url"`     // API 地址，gemini 不需要
+	Model        string               `yaml:"model"`        // 模型名称
+	APIKey       string               `yaml:"api_key"`
+	NoAuth       bool                 `yaml:"no_auth"`  // 本地部署等不需要密钥的接口
+	Headers      map[string]string    `yaml:"headers"`  // 额外的请求头，例如内部网关的鉴权
+	Script       string               `yaml:"script"`   // fake 提供商的应答脚本，为空时使用内置演示脚本
+	Cassette     string               `yaml:"cassette"` // replay 提供商回放的磁带文件
+	Pricing      Pricing              `yaml:"pricing"`
+	Capabilities ProviderCapabilities `yaml:"capabilities"`
+	Fallbacks    []string             `yaml:"fallbacks"` // 该提供商不可用时依次换用的提供商名称
+}
+
+// Enabled 是否可用：配置了密钥或不需要密钥
+func (p ProviderConfig) Enabled() bool {
+	return p.APIKey != "" || p.NoAuth || p.Type == ProviderTypeFake || p.Type == ProviderTypeReplay
+}
+
+// SupportsStream 是否支持流式输出
+func (p ProviderConfig) SupportsStream() bool {
+	return p.Capabilities.Stream == nil || *p.Capabilities.Stream
+}
+
+// SupportsStreamUsage 流式请求是否要求接口返回用量
+func (p ProviderConfig) SupportsStreamUsage() bool {
+	return p.Capabilities.StreamUsage == nil || *p.Capabilities.StreamUsage
+}
+
+// SupportsSystemMessage 是否支持 system 消息
+func (p ProviderConfig) SupportsSystemMessage() bool {
+	return p.Capabilities.SystemMessage == nil || *p.Capabilities.SystemMessage
+}
+
+// ToolConfig 工具配置
+type ToolConfig struct {
+	Name     string `yaml:"name"` // 工具名称: serper、tavily
+	Endpoint string `yaml:"endpoint"`
+	APIKey   string `yaml:"api_key"`
+}
+
+// BudgetLimits 花费上限，为 0 的项不限制
+type BudgetLimits struct {
+	MaxCost   float64 `yaml:"max_cost"`   // 最高费用，单位为美元
+	MaxTokens int     `yaml:"max_tokens"` // 最多 token，输入、输出和思考 token 合计
+	MaxCalls  int     `yaml:"max_calls"`  // 最多模型调用次数
+}
+
+// Limited 是否设置了任一上限
+func (b BudgetLimits) Limited() bool {
+	return b.MaxCost > 0 || b.MaxTokens > 0 || b.MaxCalls > 0
+}
+
+// BudgetConfig 花费预算配置
+type BudgetConfig struct {
+	Session     BudgetLimits `yaml:"session"`       // 本次运行的整个会话
+	Task        BudgetLimits `yaml:"task"`          // 单个编排任务
+	WrapUpRatio float64      `yaml:"wrap_up_ratio"` // 任一上限用到该比例时编排器开始收尾
+}
+
+// RetryConfig 模型调用失败时的重试策略，只重试限流、服务端错误和超时
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"` // 最多调用次数，包含第一次，1 表示不重试
+	BaseDelay   time.Duration `yaml:"base_delay"`   // 第一次重试前的等待时间，之后每次翻倍
+	MaxDelay    time.Duration `yaml:"max_delay"`    // 单次等待的上限
+}
+
+// CircuitBreakerConfig 每个提供商的熔断器：连续失败达到阈值后暂停调用，冷却后放行一次试探
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // 连续失败多少次后熔断
+	Cooldown         time.Duration `yaml:"cooldown"`          // 熔断后多久放行试探调用
+}
+
+// Config 系统配置
+type Config struct {
+	DefaultModel string               `yaml:"default_model"` // 启动时默认选中的提供商，为空时交互选择
+	DataDir      string               `yaml:"data_dir"`      // 会话存储目录
+	Providers    []ProviderConfig     `yaml:"providers"`
+	Tools        []ToolConfig         `yaml:"tools"`
+	Budget       BudgetConfig         `yaml:"budget"`
+	Retry        RetryConfig          `yaml:"retry"`
+	Circuit      CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	path string // 加载的配置文件路径，未使用配置文件时为空
+}
+
+// providerTypes 支持的提供商类型
+var providerTypes = map[string]bool{
+	ProviderTypeOpenAI: true,
+	ProviderTypeGemini: true,
+	ProviderTypeFake:   true,
+	ProviderTypeReplay: true,
+}
+
+// toolNames 支持的工具名称
+var toolNames = map[string]bool{
+	"serper": true,
+	"tavily": true,
+}
+
+// Default 默认配置：内置的提供商和工具，不包含任何密钥
+func Default() *Config {
+	return &Config{
+		DataDir: core.DefaultSessionDir(),
+		Providers: []ProviderConfig{
+			{
+				Name:        "doubao",
+				Type:        ProviderTypeOpenAI,
+				DisplayName: "豆包 Pro",
+				BaseURL:     "https://api.doubao.com/v1",
+				Model:       "doubao-pro",
+				Pricing:     Pricing{InputPer1M: 0.12, OutputPer1M: 0.24},
+			},
+			{
+				Name:        "deepseek",
+				Type:        ProviderTypeOpenAI,
+				DisplayName: "DeepSeek Chat",
+				BaseURL:     "https://api.deepseek.com/v1",
+				Model:       "deepseek-chat",
+				Pricing:     Pricing{InputPer1M: 0.14, OutputPer1M: 0.28},
+			},
+			{
+				Name:        "gemini",
+				Type:        ProviderTypeGemini,
+				DisplayName: "Gemini 1.5 Pro",
+				Model:       "gemini-1.5-pro",
+				Pricing:     Pricing{InputPer1M: 0.375, OutputPer1M: 1.875},
+			},
+		},
+		Tools: []ToolConfig{
+			{Name: "serper", Endpoint: "https://google.serper.dev/search"},
+			{Name: "tavily", Endpoint: "https://api.tavily.com/search"},
+		},
+		Budget:  BudgetConfig{WrapUpRatio: 0.8},
+		Retry:   RetryConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second},
+		Circuit: CircuitBreakerConfig{FailureThreshold: 3, Cooldown: time.Minute},
+	}
+}
+
+// DefaultPaths 未指定配置文件时依次查找的路径
+func DefaultPaths() []string {
+	paths := []string{"loomi.yaml"}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".loomi", "config.yaml"))
+	}
+	return paths
+}
+
+// Load 加载配置：默认配置 <- 配置文件 <- 环境变量
+// path 为空时使用 LOOMI_CONFIG，再依次查找 DefaultPaths，都不存在时只使用默认配置和环境变量
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(EnvPrefix + "CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		for _, candidate := range DefaultPaths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		}
+		// 配置文件中的 providers/tools 会整体替换默认列表
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+		}
+		cfg.path = path
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.DataDir = expandHome(cfg.DataDir)
+	for i := range cfg.Providers {
+		if cfg.Providers[i].DisplayName == "" {
+			cfg.Providers[i].DisplayName = cfg.Providers[i].Name
+		}
+		cfg.Providers[i].Script = expandHome(cfg.Providers[i].Script)
+		cfg.Providers[i].Cassette = expandHome(cfg.Providers[i].Cassette)
+	}
+	return cfg, nil
+}
+
+// expandHome 展开路径开头的 ~/
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// Path 加载的配置文件路径
+func (c *Config) Path() string {
+	return c.path
+}
+
+// EnvName 提供商或工具名称对应的环境变量名，例如 (deepseek-r1, API_KEY) -> LOOMI_DEEPSEEK_R1_API_KEY
+func EnvName(name, field string) string {
+	name = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
+	return EnvPrefix + name + "_" + field
+}
+
+// applyEnv 用环境变量覆盖配置，提供商和工具按名称匹配，例如 LOOMI_DEEPSEEK_API_KEY
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	if value, ok := lookup(EnvPrefix + "DEFAULT_MODEL"); ok {
+		c.DefaultModel = value
+	}
+	if value, ok := lookup(EnvPrefix + "DATA_DIR"); ok {
+		c.DataDir = value
+	}
+	if value, ok := lookup(EnvPrefix + "RETRY_MAX_ATTEMPTS"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 不是有效的整数: %s", EnvPrefix+"RETRY_MAX_ATTEMPTS", value)
+		}
+		c.Retry.MaxAttempts = n
+	}
+	for scope, limits := range map[string]*BudgetLimits{"SESSION": &c.Budget.Session, "TASK": &c.Budget.Task} {
+		if err := limits.applyEnv(lookup, EnvPrefix+"BUDGET_"+scope+"_"); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		if value, ok := lookup(EnvName(p.Name, "API_KEY")); ok {
+			p.APIKey = value
+		}
+		if value, ok := lookup(EnvName(p.Name, "BASE_URL")); ok {
+			p.BaseURL = value
+		}
+		if value, ok := lookup(EnvName(p.Name, "MODEL")); ok {
+			p.Model = value
+		}
+		if value, ok := lookup(EnvName(p.Name, "FALLBACKS")); ok {
+			p.Fallbacks = nil
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					p.Fallbacks = append(p.Fallbacks, name)
+				}
+			}
+		}
+		for field, target := range map[string]*float64{
+			"INPUT_PER_1M":  &p.Pricing.InputPer1M,
+			"OUTPUT_PER_1M": &p.Pricing.OutputPer1M,
+		} {
+			if value, ok := lookup(EnvName(p.Name, field)); ok {
+				price, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fmt.Errorf("环境变量 %s 不是有效的数字: %s", EnvName(p.Name, field), value)
+				}
+				*target = price
+			}
+		}
+	}
+
+	for i := range c.Tools {
+		t := &c.Tools[i]
+		if value, ok := lookup(EnvName(t.Name, "API_KEY")); ok {
+			t.APIKey = value
+		}
+		if value, ok := lookup(EnvName(t.Name, "ENDPOINT")); ok {
+			t.Endpoint = value
+		}
+	}
+	return nil
+}
+
+// applyEnv 用 prefix 开头的环境变量覆盖上限，例如 LOOMI_BUDGET_TASK_MAX_COST
+func (b *BudgetLimits) applyEnv(lookup func(string) (string, bool), prefix string) error {
+	if value, ok := lookup(prefix + "MAX_COST"); ok {
+		cost, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 不是有效的数字: %s", prefix+"MAX_COST", value)
+		}
+		b.MaxCost = cost
+	}
+	for field, target := range map[string]*int{
+		"MAX_TOKENS": &b.MaxTokens,
+		"MAX_CALLS":  &b.MaxCalls,
+	} {
+		if value, ok := lookup(prefix + field); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("环境变量 %s 不是有效的整数: %s", prefix+field, value)
+			}
+			*target = n
+		}
+	}
+	return nil
+}
+
+// Validate 校验配置，返回的错误列出所有问题
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	names := make(map[string]bool)
+	usable := 0
+	for i, p := range c.Providers {
+		where := fmt.Sprintf("providers[%d]", i)
+		if p.Name == "" {
+			add("%s: 缺少 name", where)
+		} else {
+			where = fmt.Sprintf("providers[%s]", p.Name)
+			if names[p.Name] {
+				add("%s: name 重复", where)
+			}
+			names[p.Name] = true
+		}
+		if !providerTypes[p.Type] {
+			add("%s: 不支持的 type %q", where, p.Type)
+		}
+		if p.Model == "" && p.Type != ProviderTypeFake && p.Type != ProviderTypeReplay {
+			add("%s: 缺少 model", where)
+		}
+		switch p.Type {
+		case ProviderTypeOpenAI:
+			if err := validateURL(p.BaseURL); err != nil {
+				add("%s: base_url %v", where, err)
+			}
+		case ProviderTypeGemini:
+			if p.NoAuth {
+				add("%s: gemini 不支持 no_auth", where)
+			}
+		case ProviderTypeFake:
+			if p.Script != "" {
+				if _, err := os.Stat(p.Script); err != nil {
+					add("%s: script %v", where, err)
+				}
+			}
+		case ProviderTypeReplay:
+			if p.Cassette == "" {
+				add("%s: 缺少 cassette", where)
+			} else if _, err := os.Stat(p.Cassette); err != nil {
+				add("%s: cassette %v", where, err)
+			}
+		}
+		if p.Pricing.InputPer1M < 0 || p.Pricing.OutputPer1M < 0 {
+			add("%s: pricing 不能为负数", where)
+		}
+		if p.Enabled() {
+			usable++
+		}
+	}
+	if len(c.Providers) > 0 && usable == 0 {
+		add("没有配置任何模型提供商的 api_key，请在配置文件中填写或设置环境变量（例如 %s）", EnvName(c.Providers[0].Name, "API_KEY"))
+	}
+	if len(c.Providers) == 0 {
+		add("providers 为空，至少需要配置一个模型提供商")
+	}
+	if c.DefaultModel != "" && !names[c.DefaultModel] {
+		add("default_model %q 不在 providers 中", c.DefaultModel)
+	}
+	for _, p := range c.Providers {
+		seen := make(map[string]bool)
+		for _, fallback := range p.Fallbacks {
+			switch {
+			case fallback == p.Name:
+				add("providers[%s]: fallbacks 不能包含自身", p.Name)
+			case !names[fallback]:
+				add("providers[%s]: fallbacks 中的 %q 不在 providers 中", p.Name, fallback)
+			case seen[fallback]:
+				add("providers[%s]: fallbacks 中的 %q 重复", p.Name, fallback)
+			}
+			seen[fallback] = true
+		}
+	}
+	for _, budget := range []struct {
+		scope  string
+		limits BudgetLimits
+	}{{"session", c.Budget.Session}, {"task", c.Budget.Task}} {
+		if budget.limits.MaxCost < 0 || budget.limits.MaxTokens < 0 || budget.limits.MaxCalls < 0 {
+			add("budget.%s: 上限不能为负数", budget.scope)
+		}
+	}
+	if c.Budget.WrapUpRatio <= 0 || c.Budget.WrapUpRatio > 1 {
+		add("budget.wrap_up_ratio 必须在 (0, 1] 之间")
+	}
+	if c.Retry.MaxAttempts < 1 {
+		add("retry.max_attempts 至少为 1")
+	}
+	if c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < c.Retry.BaseDelay {
+		add("retry: base_delay 不能为负数，且不能大于 max_delay")
+	}
+	if c.Circuit.FailureThreshold < 1 {
+		add("circuit_breaker.failure_threshold 至少为 1")
+	}
+	if c.Circuit.Cooldown < 0 {
+		add("circuit_breaker.cooldown 不能为负数")
+	}
+
+	tools := make(map[string]bool)
+	for i, t := range c.Tools {
+		where := fmt.Sprintf("tools[%d]", i)
+		if t.Name == "" {
+			add("%s: 缺少 name", where)
+			continue
+		}
+		where = fmt.Sprintf("tools[%s]", t.Name)
+		if tools[t.Name] {
+			add("%s: name 重复", where)
+		}
+		tools[t.Name] = true
+		if !toolNames[t.Name] {
+			add("%s: 不支持的工具", where)
+		}
+		if err := validateURL(t.Endpoint); err != nil {
+			add("%s: endpoint %v", where, err)
+		}
+	}
+
+	if c.DataDir == "" {
+		add("data_dir 不能为空")
+	}
+
+	if len(problems) > 0 {
+		source := "默认配置"
+		if c.path != "" {
+			source = c.path
+		}
+		return fmt.Errorf("配置无效（%s）:\n  - %s", source, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func validateURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("不能为空")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("不是有效的 http(s) 地址: %s", raw)
+	}
+	return nil
+}
+
+// 命令行 --fake、--replay 使用的提供商名称
+const (
+	FakeProviderName   = "fake"
+	ReplayProviderName = "replay"
+)
+
+// UseFake 启用 fake 提供商并设为默认模型，用于没有 API 密钥时离线演示和测试
+func (c *Config) UseFake(script string) {
+	fake := ProviderConfig{
+		Name:        FakeProviderName,
+		Type:        ProviderTypeFake,
+		DisplayName: "Fake（离线脚本）",
+		Script:      expandHome(script),
+	}
+	if script == "" {
+		if existing, ok := c.Provider(FakeProviderName); ok {
+			fake.Script = existing.Script
+		}
+	}
+	c.useProvider(fake)
+}
+
+// UseReplay 启用回放磁带的 replay 提供商并设为默认模型
+func (c *Config) UseReplay(cassette string) {
+	c.useProvider(ProviderConfig{
+		Name:        ReplayProviderName,
+		Type:        ProviderTypeReplay,
+		DisplayName: "Replay（回放磁带）",
+		Cassette:    expandHome(cassette),
+	})
+}
+
+// useProvider 添加或替换同名提供商，并设为默认模型
+func (c *Config) useProvider(provider ProviderConfig) {
+	c.DefaultModel = provider.Name
+	for i, p := range c.Providers {
+		if p.Name == provider.Name {
+			c.Providers[i] = provider
+			return
+		}
+	}
+	c.Providers = append(c.Providers, provider)
+}
+
+// Provider 按名称查找提供商配置
+func (c *Config) Provider(name string) (ProviderConfig, bool) {
+	for _, p := range c.Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ProviderConfig{}, false
+}
+
+// Tool 按名称查找工具配置
+func (c *Config) Tool(name string) (ToolConfig, bool) {
+	for _, t := range c.Tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return ToolConfig{}, false
+}
+
+var (
+	config   *Config
+	configMu sync.RWMutex
+)
+
+// InitConfig 加载全局配置，不做校验；需要模型的命令在启动时调用 Validate
+func InitConfig(path string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	config = cfg
+	return nil
+}
+
+// GetConfig 获取全局配置；未初始化时返回默认配置加环境变量，环境变量无效时记录日志并只使用默认配置
+func GetConfig() *Config {
+	configMu.RLock()
+	cfg := config
+	configMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	cfg = Default()
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		log.Printf("忽略环境变量中的配置: %v", err)
+		return Default()
+	}
+	return cfg
+}
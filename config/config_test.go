@@ -0,0 +1,290 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv 清空可能影响测试的 LOOMI_ 环境变量，测试结束后自动恢复
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, EnvPrefix) {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	const yamlConfig = `
+default_model: deepseek
+data_dir: /tmp/loomi-yaml
+providers:
+  - name: deepseek
+    type: openai
+    base_url: https://yaml.example.com/v1
+    model: deepseek-chat
+    api_key: yaml-key
+    pricing:
+      input_per_1m: 1
+      output_per_1m: 2
+retry:
+  max_attempts: 5
+budget:
+  task:
+    max_cost: 0.5
+`
+
+	tests := []struct {
+		name    string
+		yaml    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "配置文件覆盖默认配置",
+			yaml: yamlConfig,
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Providers) != 1 || cfg.Providers[0].APIKey != "yaml-key" || cfg.Providers[0].DisplayName != "deepseek" {
+					t.Errorf("providers 应当整体替换默认列表: %+v", cfg.Providers)
+				}
+				if cfg.DataDir != "/tmp/loomi-yaml" || cfg.Retry.MaxAttempts != 5 || cfg.Budget.Task.MaxCost != 0.5 {
+					t.Errorf("配置文件中的字段没有生效: %+v", cfg)
+				}
+				if cfg.Retry.MaxDelay != 30*time.Second || len(cfg.Tools) != 2 {
+					t.Errorf("配置文件中没有的字段应当保留默认值: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "环境变量覆盖配置文件",
+			yaml: yamlConfig,
+			env: map[string]string{
+				"LOOMI_DEFAULT_MODEL":           "gemini",
+				"LOOMI_DEEPSEEK_API_KEY":        "env-key",
+				"LOOMI_DEEPSEEK_BASE_URL":       "https://env.example.com/v1",
+				"LOOMI_DEEPSEEK_OUTPUT_PER_1M":  "3.5",
+				"LOOMI_DEEPSEEK_FALLBACKS":      " gemini, ,doubao ",
+				"LOOMI_RETRY_MAX_ATTEMPTS":      "2",
+				"LOOMI_BUDGET_TASK_MAX_CALLS":   "7",
+				"LOOMI_BUDGET_SESSION_MAX_COST": "1.25",
+				"LOOMI_SERPER_API_KEY":          "没有配置该工具时忽略",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				p := cfg.Providers[0]
+				if p.APIKey != "env-key" || p.BaseURL != "https://env.example.com/v1" || p.Model != "deepseek-chat" {
+					t.Errorf("提供商字段应当被环境变量覆盖: %+v", p)
+				}
+				if p.Pricing.InputPer1M != 1 || p.Pricing.OutputPer1M != 3.5 {
+					t.Errorf("价格覆盖错误: %+v", p.Pricing)
+				}
+				if strings.Join(p.Fallbacks, ",") != "gemini,doubao" {
+					t.Errorf("fallbacks 应当去掉空项和空白，实际 %v", p.Fallbacks)
+				}
+				if cfg.DefaultModel != "gemini" || cfg.Retry.MaxAttempts != 2 {
+					t.Errorf("全局字段应当被环境变量覆盖: %+v", cfg)
+				}
+				if cfg.Budget.Task.MaxCost != 0.5 || cfg.Budget.Task.MaxCalls != 7 || cfg.Budget.Session.MaxCost != 1.25 {
+					t.Errorf("预算覆盖错误: %+v", cfg.Budget)
+				}
+			},
+		},
+		{
+			name: "没有配置文件时覆盖默认配置",
+			env:  map[string]string{"LOOMI_DEEPSEEK_API_KEY": "env-key", "LOOMI_TAVILY_ENDPOINT": "https://tavily.example.com"},
+			check: func(t *testing.T, cfg *Config) {
+				if p, ok := cfg.Provider("deepseek"); !ok || p.APIKey != "env-key" || p.DisplayName != "DeepSeek Chat" {
+					t.Errorf("默认提供商应当被环境变量覆盖: %+v", p)
+				}
+				if cfg.Tools[1].Endpoint != "https://tavily.example.com" {
+					t.Errorf("工具地址应当被环境变量覆盖: %+v", cfg.Tools[1])
+				}
+				if cfg.Path() != "" {
+					t.Errorf("没有配置文件时路径应当为空，实际 %s", cfg.Path())
+				}
+			},
+		},
+		{
+			name:    "无效的整数",
+			env:     map[string]string{"LOOMI_RETRY_MAX_ATTEMPTS": "三"},
+			wantErr: "环境变量 LOOMI_RETRY_MAX_ATTEMPTS 不是有效的整数: 三",
+		},
+		{
+			name:    "无效的价格",
+			env:     map[string]string{"LOOMI_DOUBAO_INPUT_PER_1M": "free"},
+			wantErr: "环境变量 LOOMI_DOUBAO_INPUT_PER_1M 不是有效的数字: free",
+		},
+		{
+			name:    "无效的预算",
+			env:     map[string]string{"LOOMI_BUDGET_SESSION_MAX_TOKENS": "1e6"},
+			wantErr: "环境变量 LOOMI_BUDGET_SESSION_MAX_TOKENS 不是有效的整数: 1e6",
+		},
+		{
+			name:    "无效的配置文件",
+			yaml:    "providers: [",
+			wantErr: "解析配置文件",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			path := ""
+			if tt.yaml != "" {
+				path = filepath.Join(t.TempDir(), "loomi.yaml")
+				if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+					t.Fatalf("写入配置文件失败: %v", err)
+				}
+			} else {
+				// 避免读到工作目录或用户目录下的配置文件
+				t.Setenv("HOME", t.TempDir())
+			}
+
+			cfg, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含 %q，实际 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if path != "" && cfg.Path() != path {
+				t.Errorf("配置文件路径期望 %s，实际 %s", path, cfg.Path())
+			}
+			tt.check(t, cfg)
+		})
+	}
+
+	t.Run("LOOMI_CONFIG 指定的文件不存在", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("LOOMI_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+		if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "读取配置文件失败") {
+			t.Errorf("指定的配置文件不存在时应当报错，实际 %v", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string // 错误中应当包含的问题，为空表示校验通过
+	}{
+		{
+			name:   "默认配置加上密钥",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name:   "没有任何密钥",
+			modify: func(cfg *Config) { cfg.Providers[1].APIKey = "" },
+			want:   []string{"没有配置任何模型提供商的 api_key，请在配置文件中填写或设置环境变量（例如 LOOMI_DOUBAO_API_KEY）"},
+		},
+		{
+			name: "提供商字段错误",
+			modify: func(cfg *Config) {
+				cfg.Providers[0].Type = "claude"
+				cfg.Providers[0].Model = ""
+				cfg.Providers[1].BaseURL = "api.deepseek.com"
+				cfg.Providers[2].Name = "deepseek"
+				cfg.Providers[2].Pricing.InputPer1M = -1
+			},
+			want: []string{
+				`providers[doubao]: 不支持的 type "claude"`,
+				"providers[doubao]: 缺少 model",
+				"providers[deepseek]: base_url 不是有效的 http(s) 地址: api.deepseek.com",
+				"providers[deepseek]: name 重复",
+				"providers[deepseek]: pricing 不能为负数",
+			},
+		},
+		{
+			name: "后备提供商和默认模型",
+			modify: func(cfg *Config) {
+				cfg.DefaultModel = "claude"
+				cfg.Providers[1].Fallbacks = []string{"deepseek", "claude", "gemini", "gemini"}
+			},
+			want: []string{
+				`default_model "claude" 不在 providers 中`,
+				"providers[deepseek]: fallbacks 不能包含自身",
+				`providers[deepseek]: fallbacks 中的 "claude" 不在 providers 中`,
+				`providers[deepseek]: fallbacks 中的 "gemini" 重复`,
+			},
+		},
+		{
+			name: "预算、重试和熔断",
+			modify: func(cfg *Config) {
+				cfg.Budget.Task.MaxCalls = -1
+				cfg.Budget.WrapUpRatio = 1.5
+				cfg.Retry.MaxAttempts = 0
+				cfg.Retry.MaxDelay = time.Millisecond
+				cfg.Circuit.FailureThreshold = 0
+				cfg.Circuit.Cooldown = -time.Second
+			},
+			want: []string{
+				"budget.task: 上限不能为负数",
+				"budget.wrap_up_ratio 必须在 (0, 1] 之间",
+				"retry.max_attempts 至少为 1",
+				"retry: base_delay 不能为负数，且不能大于 max_delay",
+				"circuit_breaker.failure_threshold 至少为 1",
+				"circuit_breaker.cooldown 不能为负数",
+			},
+		},
+		{
+			name: "工具和数据目录",
+			modify: func(cfg *Config) {
+				cfg.Tools = append(cfg.Tools, ToolConfig{Name: "bing", Endpoint: "https://bing.example.com"}, ToolConfig{Name: "serper", Endpoint: "ftp://serper"})
+				cfg.DataDir = ""
+			},
+			want: []string{
+				"tools[bing]: 不支持的工具",
+				"tools[serper]: name 重复",
+				"tools[serper]: endpoint 不是有效的 http(s) 地址: ftp://serper",
+				"data_dir 不能为空",
+			},
+		},
+		{
+			name: "replay 缺少磁带",
+			modify: func(cfg *Config) {
+				cfg.Providers = append(cfg.Providers, ProviderConfig{Name: "replay", Type: ProviderTypeReplay})
+			},
+			want: []string{"providers[replay]: 缺少 cassette"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Providers[1].APIKey = "test-key"
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("校验应当通过: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("校验应当失败")
+			}
+			if !strings.HasPrefix(err.Error(), "配置无效（默认配置）:\n  - ") {
+				t.Errorf("错误信息应当注明配置来源，实际:\n%s", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), "  - "+want) {
+					t.Errorf("错误信息应当包含 %q，实际:\n%s", want, err)
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// MessageRoleTask 传递给编排器的任务消息，构成编排器的任务执行时间线
+const MessageRoleTask = "task"
+
 // Message 消息结构
 type Message struct {
 	Role      string    `json:"role"`
@@ -18,6 +21,7 @@ type ConversationManager struct {
 	mu       sync.RWMutex
 	messages []Message
 	session  string
+	onChange func()
 }
 
 var conversation *ConversationManager
@@ -29,7 +33,7 @@ func InitConversationManager() error {
 	conversationOnce.Do(func() {
 		conversation = &ConversationManager{
 			messages: make([]Message, 0),
-			session:  fmt.Sprintf("session_%d", time.Now().Unix()),
+			session:  NewSessionID(),
 		}
 	})
 	return err
@@ -42,6 +46,7 @@ func GetConversationManager() *ConversationManager {
 
 // AddMessage 添加消息
 func (c *ConversationManager) AddMessage(role, content string) {
+	defer c.changed()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
@@ -124,6 +129,7 @@ func (c *ConversationManager) GetRecentMessages(count int) []Message {
 
 // Clear 清空对话
 func (c *ConversationManager) Clear() {
+	defer c.changed()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.messages = make([]Message, 0)
@@ -138,6 +144,7 @@ func (c *ConversationManager) GetSessionID() string {
 
 // SetSessionID 设置会话ID
 func (c *ConversationManager) SetSessionID(sessionID string) {
+	defer c.changed()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.session = sessionID
@@ -170,4 +177,29 @@ func (c *ConversationManager) GetConversationSummary() string {
 	}
 	
 	return summary
-} 
\ No newline at end of file
+}
+
+// restore 用会话中的消息替换当前对话，不触发变更通知
+func (c *ConversationManager) restore(sessionID string, messages []Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session = sessionID
+	c.messages = append(make([]Message, 0, len(messages)), messages...)
+}
+
+// setOnChange 设置变更回调，回调在释放锁之后调用
+func (c *ConversationManager) setOnChange(onChange func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = onChange
+}
+
+// changed 通知变更；在修改方法中第一个 defer，保证在解锁之后执行
+func (c *ConversationManager) changed() {
+	c.mu.RLock()
+	onChange := c.onChange
+	c.mu.RUnlock()
+	if onChange != nil {
+		onChange()
+	}
+}
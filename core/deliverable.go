@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 交付物类型，与写作类行动同名
+const (
+	DeliverableXHSPost       = "xhs_post"
+	DeliverableWechatArticle = "wechat_article"
+	DeliverableTiktokScript  = "tiktok_script"
+)
+
+// XHSPost 小红书帖子
+type XHSPost struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Hashtags []string `json:"hashtags,omitempty"`
+}
+
+// ArticleSection 公众号文章小节
+type ArticleSection struct {
+	Heading string `json:"heading,omitempty"`
+	Content string `json:"content"`
+}
+
+// WechatArticle 公众号文章
+type WechatArticle struct {
+	Headline string           `json:"headline"`
+	Sections []ArticleSection `json:"sections"`
+}
+
+// TiktokScript 抖音口播稿
+type TiktokScript struct {
+	Hook    string `json:"hook"`
+	Body    string `json:"body"`
+	Closing string `json:"closing"`
+}
+
+// Deliverable 写作类行动产出的结构化交付物，Kind 决定哪个字段有效
+type Deliverable struct {
+	Kind          string         `json:"kind"`
+	XHSPost       *XHSPost       `json:"xhs_post,omitempty"`
+	WechatArticle *WechatArticle `json:"wechat_article,omitempty"`
+	TiktokScript  *TiktokScript  `json:"tiktok_script,omitempty"`
+}
+
+// Render 渲染为纯文本，作为笔记内容
+func (d *Deliverable) Render() string {
+	var b strings.Builder
+	switch d.Kind {
+	case DeliverableXHSPost:
+		if d.XHSPost == nil {
+			return ""
+		}
+		b.WriteString(fmt.Sprintf("标题：%s\n\n%s", d.XHSPost.Title, d.XHSPost.Body))
+		if len(d.XHSPost.Hashtags) > 0 {
+			b.WriteString("\n\n")
+			for i, tag := range d.XHSPost.Hashtags {
+				if i > 0 {
+					b.WriteString(" ")
+				}
+				b.WriteString("#" + tag)
+			}
+		}
+	case DeliverableWechatArticle:
+		if d.WechatArticle == nil {
+			return ""
+		}
+		b.WriteString(d.WechatArticle.Headline)
+		for _, section := range d.WechatArticle.Sections {
+			b.WriteString("\n\n")
+			if section.Heading != "" {
+				b.WriteString(section.Heading + "\n")
+			}
+			b.WriteString(section.Content)
+		}
+	case DeliverableTiktokScript:
+		if d.TiktokScript == nil {
+			return ""
+		}
+		b.WriteString(fmt.Sprintf("【开头】%s\n\n【正文】%s\n\n【结尾】%s",
+			d.TiktokScript.Hook, d.TiktokScript.Body, d.TiktokScript.Closing))
+	}
+	return b.String()
+}
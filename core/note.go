@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultNoteType 通过 AddNote 添加的无类型笔记
+	DefaultNoteType = "note"
+	// MaterialNoteType 用户提供的材料
+	MaterialNoteType = "material"
+)
+
+// Note 工作空间中的笔记，ID 由类型和序号组成（例如 profile2），可在指令中用 @ID 引用
+type Note struct {
+	ID          string       `json:"id"`
+	Type        string       `json:"type"`
+	Index       int          `json:"index"`
+	Action      string       `json:"action"`
+	Round       int          `json:"round"`
+	Instruction string       `json:"instruction,omitempty"`
+	Content     string       `json:"content"`
+	Sources     []string     `json:"sources,omitempty"`
+	Deliverable *Deliverable `json:"deliverable,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Render 渲染为提示词中使用的稳定格式
+func (n Note) Render() string {
+	return fmt.Sprintf("<%s>%s</%s>", n.ID, n.Content, n.ID)
+}
+
+var noteIDPattern = regexp.MustCompile(`^([a-z_]+?)(\d+)$`)
+
+// ParseNoteID 把笔记ID拆分为类型和序号，例如 profile2 -> (profile, 2)
+func ParseNoteID(id string) (string, int, bool) {
+	match := noteIDPattern.FindStringSubmatch(strings.ToLower(id))
+	if match == nil {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return match[1], index, true
+}
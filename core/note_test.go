@@ -0,0 +1,28 @@
+package core
+
+import "testing"
+
+func TestParseNoteID(t *testing.T) {
+	tests := []struct {
+		id        string
+		wantType  string
+		wantIndex int
+		wantOK    bool
+	}{
+		{id: "profile2", wantType: "profile", wantIndex: 2, wantOK: true},
+		{id: "XHS_POST12", wantType: "xhs_post", wantIndex: 12, wantOK: true},
+		{id: "note1", wantType: "note", wantIndex: 1, wantOK: true},
+		{id: "profile", wantOK: false},
+		{id: "12", wantOK: false},
+		{id: "profile-2", wantOK: false},
+		{id: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			noteType, index, ok := ParseNoteID(tt.id)
+			if ok != tt.wantOK || noteType != tt.wantType || index != tt.wantIndex {
+				t.Errorf("ParseNoteID(%q) = (%q, %d, %v)，期望 (%q, %d, %v)", tt.id, noteType, index, ok, tt.wantType, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// SessionData 会话的完整快照：对话消息和工作空间
+type SessionData struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Cost      float64           `json:"cost"`
+	ParentID  string            `json:"parent_id,omitempty"`  // fork 来源会话
+	ForkRound int               `json:"fork_round,omitempty"` // 从来源会话的第几轮 fork
+	Messages  []Message         `json:"messages"`
+	Workspace WorkspaceSnapshot `json:"workspace"`
+}
+
+// SessionMeta 会话列表中展示的摘要信息
+type SessionMeta struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	MessageCount int       `json:"message_count"`
+	Cost         float64   `json:"cost"`
+	ParentID     string    `json:"parent_id,omitempty"`
+}
+
+// SessionStore 会话存储接口
+type SessionStore interface {
+	Save(session *SessionData) error
+	Load(id string) (*SessionData, error)
+	List() ([]SessionMeta, error)
+	Delete(id string) error
+}
+
+// NewSessionID 生成会话ID，形如 20260102-150405-a1b2c3，按时间排序且不会在同一秒内冲突
+func NewSessionID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%s-%06d", time.Now().Format("20060102-150405"), time.Now().Nanosecond()/1000)
+	}
+	return fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
+
+// DefaultSessionDir 默认的会话存储目录
+func DefaultSessionDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".loomi", "sessions")
+	}
+	return filepath.Join(home, ".loomi", "sessions")
+}
+
+// FileSessionStore 基于文件的会话存储，每个会话一个 JSON 文件
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore 创建文件会话存储，目录不存在时自动创建
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建会话目录失败: %v", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// Dir 会话存储目录
+func (s *FileSessionStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileSessionStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("无效的会话ID: %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Save 保存会话；先写临时文件再重命名，避免进程退出时留下半个文件
+func (s *FileSessionStore) Save(session *SessionData) error {
+	path, err := s.path(session.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入会话文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入会话文件失败: %v", err)
+	}
+	return nil
+}
+
+// Load 加载会话
+func (s *FileSessionStore) Load(id string) (*SessionData, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("会话不存在: %s", id)
+		}
+		return nil, fmt.Errorf("读取会话文件失败: %v", err)
+	}
+
+	var session SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析会话文件失败: %v", err)
+	}
+	return &session, nil
+}
+
+// List 列出所有会话，最近更新的在前
+func (s *FileSessionStore) List() ([]SessionMeta, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取会话目录失败: %v", err)
+	}
+
+	var metas []SessionMeta
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		session, err := s.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		metas = append(metas, SessionMeta{
+			ID:           session.ID,
+			Title:        session.Title,
+			CreatedAt:    session.CreatedAt,
+			UpdatedAt:    session.UpdatedAt,
+			MessageCount: len(session.Messages),
+			Cost:         session.Cost,
+			ParentID:     session.ParentID,
+		})
+	}
+
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].UpdatedAt.After(metas[j].UpdatedAt)
+	})
+	return metas, nil
+}
+
+// Delete 删除会话
+func (s *FileSessionStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("会话不存在: %s", id)
+		}
+		return fmt.Errorf("删除会话失败: %v", err)
+	}
+	return nil
+}
+
+// Fork 从第 round 轮结束时的状态复制出新会话：保留该轮及之前的笔记、战术备忘和消息；新会话的费用从 0 开始累计
+func (s *SessionData) Fork(round int) (*SessionData, error) {
+	if round <= 0 {
+		return nil, fmt.Errorf("轮次必须大于0: %d", round)
+	}
+	if round > s.Workspace.Rounds {
+		return nil, fmt.Errorf("会话 %s 中没有第%d轮的执行记录", s.ID, round)
+	}
+
+	// 以该轮最后一条执行记录的时间作为截止时间；没有执行行动的轮次（如完成、改变方向）没有记录，
+	// 这时截止到之后第一条执行记录之前
+	var cutoff, next time.Time
+	record := func(r int, t time.Time) {
+		if r == round && t.After(cutoff) {
+			cutoff = t
+		} else if r > round && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	var tactics []Tactic
+	for _, tactic := range s.Workspace.Tactics {
+		if tactic.Round <= round {
+			tactics = append(tactics, tactic)
+		}
+		record(tactic.Round, tactic.Timestamp)
+	}
+	for _, note := range s.Workspace.Notes {
+		record(note.Round, note.CreatedAt)
+	}
+	keep := func(t time.Time) bool {
+		if !cutoff.IsZero() {
+			return !t.After(cutoff)
+		}
+		return next.IsZero() || t.Before(next)
+	}
+
+	var messages []Message
+	taskCount := 0
+	for _, msg := range s.Messages {
+		if !keep(msg.Timestamp) {
+			continue
+		}
+		messages = append(messages, msg)
+		if msg.Role == MessageRoleTask {
+			taskCount++
+		}
+	}
+
+	// 笔记序号按保留的笔记重新计算，fork 之后的新笔记从这里继续编号
+	var notes []Note
+	noteSeq := make(map[string]int)
+	for _, note := range s.Workspace.Notes {
+		if note.Round > round {
+			continue
+		}
+		notes = append(notes, note)
+		if note.Index > noteSeq[note.Type] {
+			noteSeq[note.Type] = note.Index
+		}
+	}
+
+	// 任务与任务消息总是同时写入，按保留的任务消息数截取
+	tasks := s.Workspace.Tasks
+	if taskCount < len(tasks) {
+		tasks = tasks[:taskCount]
+	}
+
+	context := make(map[string]interface{}, len(s.Workspace.Context))
+	for k, v := range s.Workspace.Context {
+		context[k] = v
+	}
+
+	now := time.Now()
+	return &SessionData{
+		ID:        NewSessionID(),
+		Title:     s.Title,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ParentID:  s.ID,
+		ForkRound: round,
+		Messages:  messages,
+		Workspace: WorkspaceSnapshot{
+			Notes:   notes,
+			NoteSeq: noteSeq,
+			Tasks:   append([]string(nil), tasks...),
+			Tactics: tactics,
+			Rounds:  round,
+			Context: context,
+		},
+	}, nil
+}
+
+// sessionInfo 当前会话中不属于对话和工作空间的元信息
+type sessionInfo struct {
+	createdAt time.Time
+	parentID  string
+	forkRound int
+	baseCost  float64 // 恢复会话时之前进程累计的费用
+}
+
+var (
+	sessionStore SessionStore
+	sessionMu    sync.Mutex
+	session      sessionInfo
+	sessionCost  func() float64
+)
+
+// InitSessionStore 设置会话存储并开始持久化：对话和工作空间的每次变更都会保存当前会话
+func InitSessionStore(store SessionStore) error {
+	if workspace == nil || conversation == nil {
+		return fmt.Errorf("工作空间或对话管理器未初始化")
+	}
+
+	sessionMu.Lock()
+	sessionStore = store
+	session = sessionInfo{createdAt: time.Now()}
+	sessionMu.Unlock()
+
+	workspace.setOnChange(persistSession)
+	conversation.setOnChange(persistSession)
+	return nil
+}
+
+// SetSessionCostFunc 设置本进程累计费用的来源，保存会话时写入费用
+func SetSessionCostFunc(cost func() float64) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessionCost = cost
+}
+
+// GetSessionStore 获取会话存储，未设置时返回 nil
+func GetSessionStore() SessionStore {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return sessionStore
+}
+
+// ResumeSession 从会话存储加载会话，替换当前的对话和工作空间
+func ResumeSession(id string) error {
+	store := GetSessionStore()
+	if store == nil {
+		return fmt.Errorf("会话存储未初始化")
+	}
+
+	data, err := store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	sessionMu.Lock()
+	session = sessionInfo{
+		createdAt: data.CreatedAt,
+		parentID:  data.ParentID,
+		forkRound: data.ForkRound,
+		baseCost:  data.Cost,
+	}
+	sessionMu.Unlock()
+
+	conversation.restore(data.ID, data.Messages)
+	workspace.restore(data.Workspace)
+	return nil
+}
+
+// SnapshotSession 生成当前会话的快照
+func SnapshotSession() *SessionData {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return snapshotSessionLocked()
+}
+
+// persistSession 保存当前会话；保存失败只记录日志，不影响对话
+func persistSession() {
+	store := GetSessionStore()
+	if store == nil {
+		return
+	}
+
+	// 串行保存，避免并发写入同一个文件
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if err := store.Save(snapshotSessionLocked()); err != nil {
+		fmt.Fprintf(os.Stderr, "保存会话失败: %v\n", err)
+	}
+}
+
+func snapshotSessionLocked() *SessionData {
+	cost := session.baseCost
+	if sessionCost != nil {
+		cost += sessionCost()
+	}
+
+	messages := conversation.GetMessages()
+	return &SessionData{
+		ID:        conversation.GetSessionID(),
+		Title:     sessionTitle(messages),
+		CreatedAt: session.createdAt,
+		UpdatedAt: time.Now(),
+		Cost:      cost,
+		ParentID:  session.parentID,
+		ForkRound: session.forkRound,
+		Messages:  messages,
+		Workspace: workspace.Snapshot(),
+	}
+}
+
+// sessionTitle 用第一条用户消息作为会话标题
+func sessionTitle(messages []Message) string {
+	for _, msg := range messages {
+		if msg.Role == "user" {
+			title := strings.Join(strings.Fields(msg.Content), " ")
+			if utf8.RuneCountInString(title) > 30 {
+				title = string([]rune(title)[:30]) + "…"
+			}
+			return title
+		}
+	}
+	return ""
+}
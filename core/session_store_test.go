@@ -0,0 +1,50 @@
+package core
+
+import "testing"
+
+func TestFileSessionStore(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建会话存储失败: %v", err)
+	}
+
+	session := &SessionData{
+		ID:       NewSessionID(),
+		Title:    "测试会话",
+		Messages: []Message{{Role: "user", Content: "测试消息"}},
+		Workspace: WorkspaceSnapshot{
+			Notes:   []Note{{ID: "profile1", Type: "profile", Index: 1, Content: "测试画像"}},
+			NoteSeq: map[string]int{"profile": 1},
+			Rounds:  2,
+		},
+	}
+	if err := store.Save(session); err != nil {
+		t.Fatalf("保存会话失败: %v", err)
+	}
+
+	loaded, err := store.Load(session.ID)
+	if err != nil {
+		t.Fatalf("加载会话失败: %v", err)
+	}
+	if len(loaded.Messages) != 1 || len(loaded.Workspace.Notes) != 1 || loaded.Workspace.Rounds != 2 {
+		t.Errorf("加载的会话内容错误: %+v", loaded)
+	}
+
+	metas, err := store.List()
+	if err != nil || len(metas) != 1 || metas[0].MessageCount != 1 {
+		t.Errorf("列出会话错误: %+v, %v", metas, err)
+	}
+
+	if err := store.Delete(session.ID); err != nil {
+		t.Fatalf("删除会话失败: %v", err)
+	}
+	if _, err := store.Load(session.ID); err == nil {
+		t.Error("删除后仍能加载会话")
+	}
+
+	for _, id := range []string{"", "../etc", ".hidden", `a\b`} {
+		if _, err := store.Load(id); err == nil {
+			t.Errorf("无效的会话ID %q 应当报错", id)
+		}
+	}
+}
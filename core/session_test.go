@@ -0,0 +1,79 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// newForkTestSession 三轮执行记录：第1轮 profile，第2轮完成任务没有执行行动，第3轮 insight
+func newForkTestSession() *SessionData {
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	return &SessionData{
+		ID:    "20260102-150000-a1b2c3",
+		Title: "测试会话",
+		Cost:  1.5,
+		Messages: []Message{
+			{Role: MessageRoleTask, Content: "写一篇帖子", Timestamp: at(0)},
+			{Role: "assistant", Content: "第一次交付", Timestamp: at(3)},
+			{Role: MessageRoleTask, Content: "再补充洞察", Timestamp: at(4)},
+			{Role: "assistant", Content: "第二次交付", Timestamp: at(6)},
+		},
+		Workspace: WorkspaceSnapshot{
+			Notes: []Note{
+				{ID: "profile1", Type: "profile", Index: 1, Round: 1, CreatedAt: at(1)},
+				{ID: "insight1", Type: "insight", Index: 1, Round: 3, CreatedAt: at(5)},
+			},
+			NoteSeq: map[string]int{"profile": 1, "insight": 1},
+			Tasks:   []string{"写一篇帖子", "再补充洞察"},
+			Tactics: []Tactic{
+				{Round: 1, Action: "profile", Timestamp: at(1)},
+				{Round: 3, Action: "insight", Timestamp: at(5)},
+			},
+			Rounds: 3,
+		},
+	}
+}
+
+func TestSessionFork(t *testing.T) {
+	session := newForkTestSession()
+
+	forked, err := session.Fork(1)
+	if err != nil {
+		t.Fatalf("fork 失败: %v", err)
+	}
+	if forked.ParentID != session.ID || forked.ForkRound != 1 || forked.ID == session.ID {
+		t.Errorf("fork 来源记录错误: %+v", forked)
+	}
+	if forked.Cost != 0 {
+		t.Errorf("新会话的费用应当从 0 开始，实际 %v", forked.Cost)
+	}
+	if len(forked.Messages) != 1 || len(forked.Workspace.Notes) != 1 || len(forked.Workspace.Tasks) != 1 {
+		t.Errorf("第1轮之后的内容不应保留: %+v", forked)
+	}
+}
+
+func TestSessionForkRoundWithoutTactic(t *testing.T) {
+	session := newForkTestSession()
+
+	// 第2轮没有执行行动，截止到第3轮的第一条记录之前
+	forked, err := session.Fork(2)
+	if err != nil {
+		t.Fatalf("没有战术备忘的轮次也应当可以 fork: %v", err)
+	}
+	if len(forked.Messages) != 3 || len(forked.Workspace.Notes) != 1 || len(forked.Workspace.Tactics) != 1 {
+		t.Errorf("fork 到第2轮的内容错误: 消息 %d 条，笔记 %d 条，战术 %d 条",
+			len(forked.Messages), len(forked.Workspace.Notes), len(forked.Workspace.Tactics))
+	}
+	if forked.Workspace.NoteSeq["insight"] != 0 {
+		t.Errorf("第3轮的笔记序号不应保留: %v", forked.Workspace.NoteSeq)
+	}
+
+	for _, round := range []int{0, 4} {
+		if _, err := session.Fork(round); err == nil {
+			t.Errorf("不存在的第%d轮应当报错", round)
+		}
+	}
+}
@@ -2,15 +2,44 @@ package core
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
+// Tactic 编排器每轮的战术备忘
+type Tactic struct {
+	Round     int       `json:"round"`
+	Action    string    `json:"action"`
+	Memo      string    `json:"memo"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// String 按提示词约定的格式渲染战术备忘
+func (t Tactic) String() string {
+	return fmt.Sprintf("Round%d: executed '%s'. memo: %s", t.Round, t.Action, t.Memo)
+}
+
 // WorkSpace 工作空间
 type WorkSpace struct {
 	mu       sync.RWMutex
-	notes    []string
+	notes    []Note
+	noteSeq  map[string]int
 	tasks    []string
+	tactics  []Tactic
+	rounds   int
 	context  map[string]interface{}
+	onChange func()
+}
+
+// WorkspaceSnapshot 工作空间的可序列化快照
+type WorkspaceSnapshot struct {
+	Notes   []Note                 `json:"notes"`
+	NoteSeq map[string]int         `json:"note_seq"`
+	Tasks   []string               `json:"tasks"`
+	Tactics []Tactic               `json:"tactics"`
+	Rounds  int                    `json:"rounds"`
+	Context map[string]interface{} `json:"context"`
 }
 
 var workspace *WorkSpace
@@ -21,8 +50,10 @@ func InitWorkspace() error {
 	var err error
 	workspaceOnce.Do(func() {
 		workspace = &WorkSpace{
-			notes:   make([]string, 0),
+			notes:   make([]Note, 0),
+			noteSeq: make(map[string]int),
 			tasks:   make([]string, 0),
+			tactics: make([]Tactic, 0),
 			context: make(map[string]interface{}),
 		}
 	})
@@ -34,25 +65,127 @@ func GetWorkspace() *WorkSpace {
 	return workspace
 }
 
-// AddNote 添加笔记
+// AddNote 添加笔记（无类型笔记，ID 形如 note1）
 func (w *WorkSpace) AddNote(note string) {
+	w.SaveNote(Note{
+		Type:    DefaultNoteType,
+		Action:  DefaultNoteType,
+		Content: note,
+	})
+}
+
+// SaveNote 保存笔记；ID 为空时按类型自动分配序号，指定 ID 时不能与已有笔记重复；保存失败时不通知变更
+func (w *WorkSpace) SaveNote(note Note) (saved Note, err error) {
+	defer func() {
+		if err == nil {
+			w.changed()
+		}
+	}()
 	w.mu.Lock()
 	defer w.mu.Unlock()
+
+	if note.ID != "" {
+		noteType, index, ok := ParseNoteID(note.ID)
+		if !ok {
+			return Note{}, fmt.Errorf("无效的笔记ID: %s", note.ID)
+		}
+		if _, exists := w.findNote(note.ID); exists {
+			return Note{}, fmt.Errorf("笔记ID已存在: %s", note.ID)
+		}
+		note.Type = noteType
+		note.Index = index
+		if index > w.noteSeq[noteType] {
+			w.noteSeq[noteType] = index
+		}
+	} else {
+		if note.Type == "" {
+			return Note{}, fmt.Errorf("笔记类型不能为空")
+		}
+		w.noteSeq[note.Type]++
+		note.Index = w.noteSeq[note.Type]
+		note.ID = fmt.Sprintf("%s%d", note.Type, note.Index)
+	}
+
+	if note.Action == "" {
+		note.Action = note.Type
+	}
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = time.Now()
+	}
+
 	w.notes = append(w.notes, note)
+	return note, nil
 }
 
-// GetNotes 获取所有笔记
+// findNote 按ID查找笔记（调用方需持有锁）
+func (w *WorkSpace) findNote(id string) (Note, bool) {
+	for _, note := range w.notes {
+		if note.ID == id {
+			return note, true
+		}
+	}
+	return Note{}, false
+}
+
+// GetNote 按ID获取笔记，例如 GetNote("profile2")
+func (w *WorkSpace) GetNote(id string) (Note, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.findNote(strings.ToLower(strings.TrimPrefix(id, "@")))
+}
+
+// ListNotes 按创建顺序获取所有笔记
+func (w *WorkSpace) ListNotes() []Note {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	notes := make([]Note, len(w.notes))
+	copy(notes, w.notes)
+	return notes
+}
+
+// GetNotesByAction 获取指定行动产出的笔记
+func (w *WorkSpace) GetNotesByAction(action string) []Note {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var notes []Note
+	for _, note := range w.notes {
+		if note.Action == action {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// GetNotes 获取所有笔记内容
 func (w *WorkSpace) GetNotes() []string {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 	
 	notes := make([]string, len(w.notes))
-	copy(notes, w.notes)
+	for i, note := range w.notes {
+		notes[i] = note.Content
+	}
 	return notes
 }
 
+// RenderNotes 按创建顺序渲染所有笔记，供提示词上下文使用
+func (w *WorkSpace) RenderNotes() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var b strings.Builder
+	for _, note := range w.notes {
+		b.WriteString(note.Render())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 // AddTask 添加任务
 func (w *WorkSpace) AddTask(task string) {
+	defer w.changed()
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.tasks = append(w.tasks, task)
@@ -68,8 +201,39 @@ func (w *WorkSpace) GetTasks() []string {
 	return tasks
 }
 
+// NextRound 分配下一个ReAct轮次编号（会话内全局递增）
+func (w *WorkSpace) NextRound() int {
+	defer w.changed()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rounds++
+	return w.rounds
+}
+
+// AddTactic 添加战术备忘
+func (w *WorkSpace) AddTactic(tactic Tactic) {
+	defer w.changed()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if tactic.Timestamp.IsZero() {
+		tactic.Timestamp = time.Now()
+	}
+	w.tactics = append(w.tactics, tactic)
+}
+
+// GetTactics 获取所有战术备忘
+func (w *WorkSpace) GetTactics() []Tactic {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	
+	tactics := make([]Tactic, len(w.tactics))
+	copy(tactics, w.tactics)
+	return tactics
+}
+
 // SetContext 设置上下文
 func (w *WorkSpace) SetContext(key string, value interface{}) {
+	defer w.changed()
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.context[key] = value
@@ -97,10 +261,14 @@ func (w *WorkSpace) GetAllContext() map[string]interface{} {
 
 // Clear 清空工作空间
 func (w *WorkSpace) Clear() {
+	defer w.changed()
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.notes = make([]string, 0)
+	w.notes = make([]Note, 0)
+	w.noteSeq = make(map[string]int)
 	w.tasks = make([]string, 0)
+	w.tactics = make([]Tactic, 0)
+	w.rounds = 0
 	w.context = make(map[string]interface{})
 }
 
@@ -112,11 +280,73 @@ func (w *WorkSpace) GetSummary() string {
 	summary := fmt.Sprintf("工作空间状态:\n")
 	summary += fmt.Sprintf("- 笔记数量: %d\n", len(w.notes))
 	summary += fmt.Sprintf("- 任务数量: %d\n", len(w.tasks))
+	summary += fmt.Sprintf("- 战术备忘数量: %d\n", len(w.tactics))
 	summary += fmt.Sprintf("- 上下文键数量: %d\n", len(w.context))
 	
 	return summary
 }
 
+// Snapshot 生成工作空间快照
+func (w *WorkSpace) Snapshot() WorkspaceSnapshot {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	snapshot := WorkspaceSnapshot{
+		Notes:   make([]Note, len(w.notes)),
+		NoteSeq: make(map[string]int, len(w.noteSeq)),
+		Tasks:   make([]string, len(w.tasks)),
+		Tactics: make([]Tactic, len(w.tactics)),
+		Rounds:  w.rounds,
+		Context: make(map[string]interface{}, len(w.context)),
+	}
+	copy(snapshot.Notes, w.notes)
+	copy(snapshot.Tasks, w.tasks)
+	copy(snapshot.Tactics, w.tactics)
+	for k, v := range w.noteSeq {
+		snapshot.NoteSeq[k] = v
+	}
+	for k, v := range w.context {
+		snapshot.Context[k] = v
+	}
+	return snapshot
+}
+
+// restore 用快照替换工作空间内容，不触发变更通知
+func (w *WorkSpace) restore(snapshot WorkspaceSnapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.notes = append(make([]Note, 0, len(snapshot.Notes)), snapshot.Notes...)
+	w.tasks = append(make([]string, 0, len(snapshot.Tasks)), snapshot.Tasks...)
+	w.tactics = append(make([]Tactic, 0, len(snapshot.Tactics)), snapshot.Tactics...)
+	w.rounds = snapshot.Rounds
+	w.noteSeq = make(map[string]int)
+	for k, v := range snapshot.NoteSeq {
+		w.noteSeq[k] = v
+	}
+	w.context = make(map[string]interface{})
+	for k, v := range snapshot.Context {
+		w.context[k] = v
+	}
+}
+
+// setOnChange 设置变更回调，回调在释放锁之后调用
+func (w *WorkSpace) setOnChange(onChange func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = onChange
+}
+
+// changed 通知变更；在修改方法中第一个 defer，保证在解锁之后执行
+func (w *WorkSpace) changed() {
+	w.mu.RLock()
+	onChange := w.onChange
+	w.mu.RUnlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
 // Cleanup 清理资源
 func Cleanup() {
 	// 清理工作空间资源
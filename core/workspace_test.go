@@ -0,0 +1,101 @@
+package core
+
+import "testing"
+
+// newTestWorkspace 创建独立于全局实例的工作空间，并统计变更通知次数
+func newTestWorkspace() (*WorkSpace, *int) {
+	w := &WorkSpace{
+		notes:   make([]Note, 0),
+		noteSeq: make(map[string]int),
+		tasks:   make([]string, 0),
+		tactics: make([]Tactic, 0),
+		context: make(map[string]interface{}),
+	}
+	changes := 0
+	w.setOnChange(func() { changes++ })
+	return w, &changes
+}
+
+func TestWorkspaceSaveNote(t *testing.T) {
+	w, changes := newTestWorkspace()
+
+	note, err := w.SaveNote(Note{Type: "profile", Round: 1, Content: "测试画像"})
+	if err != nil {
+		t.Fatalf("保存笔记失败: %v", err)
+	}
+	if note.ID != "profile1" || note.Index != 1 || note.Action != "profile" || note.CreatedAt.IsZero() {
+		t.Errorf("自动分配的笔记字段错误: %+v", note)
+	}
+
+	// 指定 ID 时沿用其中的序号，后续自动分配从更大的序号继续
+	if note, err := w.SaveNote(Note{ID: "profile5", Content: "指定序号"}); err != nil || note.Type != "profile" || note.Index != 5 {
+		t.Fatalf("指定ID保存笔记错误: %+v (%v)", note, err)
+	}
+	if note, _ := w.SaveNote(Note{Type: "profile", Content: "继续编号"}); note.ID != "profile6" {
+		t.Errorf("自动分配的序号应当为 profile6，实际 %s", note.ID)
+	}
+	if *changes != 3 {
+		t.Errorf("成功保存 3 条笔记应当通知 3 次变更，实际 %d", *changes)
+	}
+
+	invalid := []struct {
+		name string
+		note Note
+	}{
+		{name: "无效的ID", note: Note{ID: "profile-x"}},
+		{name: "重复的ID", note: Note{ID: "profile1"}},
+		{name: "没有类型", note: Note{Content: "无类型"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *changes
+			if _, err := w.SaveNote(tt.note); err == nil {
+				t.Error("应当保存失败")
+			}
+			if *changes != before {
+				t.Error("保存失败时不应通知变更")
+			}
+		})
+	}
+	if got := len(w.ListNotes()); got != 3 {
+		t.Errorf("保存失败的笔记不应写入，实际 %d 条", got)
+	}
+}
+
+func TestWorkspaceGetNote(t *testing.T) {
+	w, _ := newTestWorkspace()
+	w.AddNote("测试笔记")
+	w.SaveNote(Note{Type: "profile", Action: "profile", Round: 1, Content: "测试画像"})
+
+	for _, id := range []string{"profile1", "@profile1", "@Profile1"} {
+		if found, exists := w.GetNote(id); !exists || found.Content != "测试画像" {
+			t.Errorf("按ID %q 查找笔记失败", id)
+		}
+	}
+	if _, exists := w.GetNote("profile2"); exists {
+		t.Error("不存在的笔记不应找到")
+	}
+	if notes := w.GetNotes(); len(notes) != 2 {
+		t.Errorf("笔记数量错误，期望 2，实际 %d", len(notes))
+	}
+	if note, exists := w.GetNote("note1"); !exists || note.Type != DefaultNoteType {
+		t.Errorf("AddNote 应当保存为无类型笔记，实际 %+v", note)
+	}
+	if len(w.GetNotesByAction("profile")) != 1 {
+		t.Error("按行动列出笔记失败")
+	}
+}
+
+func TestWorkspaceTasksAndContext(t *testing.T) {
+	w, _ := newTestWorkspace()
+
+	w.AddTask("测试任务")
+	if tasks := w.GetTasks(); len(tasks) != 1 || tasks[0] != "测试任务" {
+		t.Errorf("任务列表错误: %v", tasks)
+	}
+
+	w.SetContext("test_key", "test_value")
+	if value, exists := w.GetContext("test_key"); !exists || value != "test_value" {
+		t.Errorf("上下文值错误，期望 test_value，实际 %v", value)
+	}
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// htmlTemplate 自包含的 HTML 报告模板，样式内联，不依赖外部资源
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"paragraphs": paragraphs,
+	"date": func(r *Report) string {
+		return r.CreatedAt.Format("2006-01-02 15:04")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; background: #f6f5f2; color: #222; font: 16px/1.75 -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; }
+main { max-width: 760px; margin: 0 auto; padding: 48px 24px; }
+header { border-bottom: 2px solid #ff2442; margin-bottom: 32px; }
+h1 { font-size: 28px; margin: 0 0 8px; }
+h2 { font-size: 20px; margin: 40px 0 16px; }
+.meta { color: #888; font-size: 13px; margin-bottom: 16px; }
+.card { background: #fff; border-radius: 12px; padding: 20px 24px; margin-bottom: 16px; box-shadow: 0 1px 3px rgba(0,0,0,.06); }
+.card h3 { font-size: 18px; margin: 0 0 12px; }
+.card h4 { font-size: 16px; margin: 20px 0 8px; }
+.label { display: inline-block; font-size: 12px; color: #ff2442; border: 1px solid #ff2442; border-radius: 4px; padding: 0 6px; margin-bottom: 8px; }
+.tags { color: #13386c; }
+.note-id { color: #888; font-size: 13px; }
+p { margin: 0 0 12px; }
+a { color: #13386c; word-break: break-all; }
+</style>
+</head>
+<body>
+<main>
+<header>
+<h1>{{.Title}}</h1>
+<div class="meta">会话 {{.SessionID}} · 创建于 {{date .}}</div>
+</header>
+
+<h2>交付内容</h2>
+{{range .Deliverables}}
+<section class="card">
+<span class="label">{{.Label}} · Round{{.Round}}</span>
+{{with .Deliverable.XHSPost}}<h3>{{.Title}}</h3>{{paragraphs .Body}}{{if .Hashtags}}<p class="tags">{{range .Hashtags}}#{{.}} {{end}}</p>{{end}}{{end}}
+{{with .Deliverable.WechatArticle}}<h3>{{.Headline}}</h3>{{range .Sections}}{{if .Heading}}<h4>{{.Heading}}</h4>{{end}}{{paragraphs .Content}}{{end}}{{end}}
+{{with .Deliverable.TiktokScript}}<h4>开头</h4>{{paragraphs .Hook}}<h4>正文</h4>{{paragraphs .Body}}<h4>结尾</h4>{{paragraphs .Closing}}{{end}}
+</section>
+{{else}}
+<p class="meta">暂无交付内容</p>
+{{end}}
+
+{{if .Notes}}<h2>支撑笔记</h2>
+{{range .Notes}}
+<section class="card">
+<h3>{{.Label}}</h3>
+{{range .Notes}}<p class="note-id">{{.ID}} · Round{{.Round}}</p>{{paragraphs .Content}}{{end}}
+</section>
+{{end}}{{end}}
+
+{{if .Sources}}<h2>搜索来源</h2>
+{{range .Sources}}
+<section class="card">
+<p class="note-id">{{.NoteID}} · Round{{.Round}}</p>
+{{paragraphs .Summary}}
+<ul>{{range .URLs}}<li><a href="{{.}}">{{.}}</a></li>{{end}}</ul>
+</section>
+{{end}}{{end}}
+</main>
+</body>
+</html>
+`))
+
+// paragraphs 把文本按空行拆成段落，段内换行保留为 <br>
+func paragraphs(text string) template.HTML {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = template.HTMLEscapeString(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return template.HTML(b.String())
+}
+
+// HTML 渲染为自包含的 HTML 报告，可直接发送给客户
+func (r *Report) HTML() (string, error) {
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, r); err != nil {
+		return "", fmt.Errorf("渲染 HTML 报告失败: %v", err)
+	}
+	return b.String(), nil
+}
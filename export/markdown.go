@@ -0,0 +1,81 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"loomi2.0/core"
+)
+
+// Markdown 渲染为 Markdown，便于阅读和二次编辑
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# %s\n\n", r.Title))
+	b.WriteString(fmt.Sprintf("- 会话: %s\n", r.SessionID))
+	b.WriteString(fmt.Sprintf("- 创建时间: %s\n", r.CreatedAt.Format("2006-01-02 15:04")))
+	b.WriteString(fmt.Sprintf("- 导出时间: %s\n\n", r.ExportedAt.Format("2006-01-02 15:04")))
+
+	b.WriteString("## 交付内容\n\n")
+	if len(r.Deliverables) == 0 {
+		b.WriteString("（暂无）\n\n")
+	}
+	for _, entry := range r.Deliverables {
+		b.WriteString(fmt.Sprintf("### %s · %s（Round%d）\n\n", entry.Label, entry.NoteID, entry.Round))
+		b.WriteString(markdownDeliverable(entry.Deliverable))
+		b.WriteString("\n\n")
+	}
+
+	if len(r.Notes) > 0 {
+		b.WriteString("## 支撑笔记\n\n")
+		for _, group := range r.Notes {
+			b.WriteString(fmt.Sprintf("### %s\n\n", group.Label))
+			for _, note := range group.Notes {
+				b.WriteString(fmt.Sprintf("**%s**（Round%d）\n\n%s\n\n", note.ID, note.Round, note.Content))
+			}
+		}
+	}
+
+	if len(r.Sources) > 0 {
+		b.WriteString("## 搜索来源\n\n")
+		for _, source := range r.Sources {
+			b.WriteString(fmt.Sprintf("**%s**（Round%d）\n\n%s\n\n", source.NoteID, source.Round, source.Summary))
+			for _, url := range source.URLs {
+				b.WriteString(fmt.Sprintf("- <%s>\n", url))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// markdownDeliverable 按交付物类型渲染 Markdown
+func markdownDeliverable(d *core.Deliverable) string {
+	var b strings.Builder
+	switch {
+	case d.XHSPost != nil:
+		b.WriteString(fmt.Sprintf("**%s**\n\n%s", d.XHSPost.Title, d.XHSPost.Body))
+		if len(d.XHSPost.Hashtags) > 0 {
+			tags := make([]string, len(d.XHSPost.Hashtags))
+			for i, tag := range d.XHSPost.Hashtags {
+				tags[i] = "#" + tag
+			}
+			b.WriteString("\n\n" + strings.Join(tags, " "))
+		}
+	case d.WechatArticle != nil:
+		b.WriteString(fmt.Sprintf("**%s**", d.WechatArticle.Headline))
+		for _, section := range d.WechatArticle.Sections {
+			if section.Heading != "" {
+				b.WriteString(fmt.Sprintf("\n\n#### %s", section.Heading))
+			}
+			b.WriteString("\n\n" + section.Content)
+		}
+	case d.TiktokScript != nil:
+		b.WriteString(fmt.Sprintf("**开头**：%s\n\n**正文**：%s\n\n**结尾**：%s",
+			d.TiktokScript.Hook, d.TiktokScript.Body, d.TiktokScript.Closing))
+	default:
+		b.WriteString(d.Render())
+	}
+	return b.String()
+}
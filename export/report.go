@@ -0,0 +1,232 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"loomi2.0/core"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
+)
+
+// ParseFormat 解析导出格式，支持 md、markdown、json、html
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("不支持的导出格式: %s（可选 md、json、html）", name)
+}
+
+// Extension 导出文件的扩展名
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	}
+	return ".json"
+}
+
+// websearchNoteType websearch 行动产出的笔记类型
+const websearchNoteType = "websearch"
+
+// noteTypeLabels 支撑笔记类型的展示名称，同时决定导出时的顺序
+var noteTypeLabels = []struct {
+	Type  string
+	Label string
+}{
+	{"insight", "洞察"},
+	{"profile", "受众画像"},
+	{"hitpoint", "内容打点"},
+	{"style", "文体风格"},
+	{"brand_analysis", "品牌分析"},
+	{"content_analysis", "内容分析"},
+	{core.MaterialNoteType, "用户材料"},
+	{core.DefaultNoteType, "笔记"},
+}
+
+// deliverableLabels 交付物类型的展示名称
+var deliverableLabels = map[string]string{
+	core.DeliverableXHSPost:       "小红书帖子",
+	core.DeliverableWechatArticle: "公众号文章",
+	core.DeliverableTiktokScript:  "抖音口播稿",
+}
+
+// DeliverableEntry 报告中的一篇交付物
+type DeliverableEntry struct {
+	NoteID      string            `json:"note_id"`
+	Round       int               `json:"round"`
+	Kind        string            `json:"kind"`
+	Label       string            `json:"label"`
+	Instruction string            `json:"instruction,omitempty"`
+	Deliverable *core.Deliverable `json:"deliverable"`
+}
+
+// NoteEntry 报告中的一条支撑笔记
+type NoteEntry struct {
+	ID      string `json:"id"`
+	Round   int    `json:"round"`
+	Content string `json:"content"`
+}
+
+// NoteGroup 同一类型的支撑笔记
+type NoteGroup struct {
+	Type  string      `json:"type"`
+	Label string      `json:"label"`
+	Notes []NoteEntry `json:"notes"`
+}
+
+// SourceEntry 一次搜索的摘要及其来源
+type SourceEntry struct {
+	NoteID  string   `json:"note_id"`
+	Round   int      `json:"round"`
+	Summary string   `json:"summary"`
+	URLs    []string `json:"urls"`
+}
+
+// Report 会话的导出报告：交付物、支撑笔记和搜索来源
+type Report struct {
+	SessionID    string             `json:"session_id"`
+	Title        string             `json:"title"`
+	CreatedAt    time.Time          `json:"created_at"`
+	ExportedAt   time.Time          `json:"exported_at"`
+	Cost         float64            `json:"cost"`
+	Deliverables []DeliverableEntry `json:"deliverables"`
+	Notes        []NoteGroup        `json:"notes"`
+	Sources      []SourceEntry      `json:"sources"`
+}
+
+// BuildReport 从会话快照构建导出报告
+func BuildReport(session *core.SessionData) *Report {
+	report := &Report{
+		SessionID:    session.ID,
+		Title:        session.Title,
+		CreatedAt:    session.CreatedAt,
+		ExportedAt:   time.Now(),
+		Cost:         session.Cost,
+		Deliverables: []DeliverableEntry{},
+		Notes:        []NoteGroup{},
+		Sources:      []SourceEntry{},
+	}
+	if report.Title == "" {
+		report.Title = session.ID
+	}
+
+	groups := make(map[string]*NoteGroup)
+	for _, note := range session.Workspace.Notes {
+		switch {
+		case note.Deliverable != nil:
+			report.Deliverables = append(report.Deliverables, DeliverableEntry{
+				NoteID:      note.ID,
+				Round:       note.Round,
+				Kind:        note.Deliverable.Kind,
+				Label:       deliverableLabels[note.Deliverable.Kind],
+				Instruction: note.Instruction,
+				Deliverable: note.Deliverable,
+			})
+		case note.Type == websearchNoteType:
+			// websearch 笔记内容以"来源:"行结尾，摘要只取前面的部分
+			summary, _, _ := strings.Cut(note.Content, "\n来源:")
+			report.Sources = append(report.Sources, SourceEntry{
+				NoteID:  note.ID,
+				Round:   note.Round,
+				Summary: strings.TrimSpace(summary),
+				URLs:    note.Sources,
+			})
+		default:
+			group, exists := groups[note.Type]
+			if !exists {
+				group = &NoteGroup{Type: note.Type, Label: noteTypeLabel(note.Type)}
+				groups[note.Type] = group
+			}
+			group.Notes = append(group.Notes, NoteEntry{ID: note.ID, Round: note.Round, Content: note.Content})
+		}
+	}
+
+	// 已知类型按固定顺序在前，其余按类型名排序
+	for _, known := range noteTypeLabels {
+		if group, exists := groups[known.Type]; exists {
+			report.Notes = append(report.Notes, *group)
+			delete(groups, known.Type)
+		}
+	}
+	var others []string
+	for noteType := range groups {
+		others = append(others, noteType)
+	}
+	sort.Strings(others)
+	for _, noteType := range others {
+		report.Notes = append(report.Notes, *groups[noteType])
+	}
+
+	return report
+}
+
+func noteTypeLabel(noteType string) string {
+	for _, known := range noteTypeLabels {
+		if known.Type == noteType {
+			return known.Label
+		}
+	}
+	return noteType
+}
+
+// Render 按格式渲染报告
+func (r *Report) Render(format Format) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return []byte(r.Markdown()), nil
+	case FormatJSON:
+		return r.JSON()
+	case FormatHTML:
+		html, err := r.HTML()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(html), nil
+	}
+	return nil, fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// JSON 渲染为 JSON，供下游工具使用
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化报告失败: %v", err)
+	}
+	return data, nil
+}
+
+// WriteFile 把会话导出到文件
+func WriteFile(session *core.SessionData, format Format, path string) error {
+	data, err := BuildReport(session).Render(format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("写入导出文件失败: %v", err)
+	}
+	return nil
+}
+
+// DefaultFileName 默认的导出文件名，例如 loomi-<session>.md
+func DefaultFileName(sessionID string, format Format) string {
+	return "loomi-" + sessionID + format.Extension()
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"loomi2.0/core"
+)
+
+var update = flag.Bool("update", false, "用当前输出更新 testdata 中的 golden 文件")
+
+// newGoldenSession 包含交付物、支撑笔记、搜索来源和战术备忘的会话
+func newGoldenSession() *core.SessionData {
+	start := time.Date(2026, 3, 8, 9, 30, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	return &core.SessionData{
+		ID:        "20260308-093000-golden",
+		Title:     "通勤防晒 <小红书>",
+		CreatedAt: start,
+		Cost:      0.0123,
+		Workspace: core.WorkspaceSnapshot{
+			Notes: []core.Note{
+				{ID: "websearch1", Type: "websearch", Index: 1, Action: "websearch", Round: 1, Content: "通勤党更看重清爽不黏腻。\n来源: https://example.com/1 https://example.com/3", Sources: []string{"https://example.com/1", "https://example.com/3"}, CreatedAt: at(1)},
+				{ID: "profile1", Type: "profile", Index: 1, Action: "profile", Round: 1, Content: "25 岁通勤白领\n\n早上时间紧，讨厌补涂", CreatedAt: at(2)},
+				{ID: "insight1", Type: "insight", Index: 1, Action: "insight", Round: 2, Content: "怕麻烦胜过怕晒黑", CreatedAt: at(3)},
+				{ID: "trend1", Type: "trend", Index: 1, Action: "trend", Round: 2, Content: "自定义类型排在已知类型之后", CreatedAt: at(4)},
+				{ID: "xhs_post1", Type: "xhs_post", Index: 1, Action: "xhs_post", Round: 3, Instruction: "基于 @insight1 写帖子", CreatedAt: at(5), Deliverable: &core.Deliverable{
+					Kind:    core.DeliverableXHSPost,
+					XHSPost: &core.XHSPost{Title: "通勤党的防晒只要一步", Body: "早上出门前抹一层。\n\n不用补涂 & 不黏腻。", Hashtags: []string{"防晒", "通勤"}},
+				}},
+				{ID: "wechat_article1", Type: "wechat_article", Index: 1, Action: "wechat_article", Round: 4, CreatedAt: at(6), Deliverable: &core.Deliverable{
+					Kind: core.DeliverableWechatArticle,
+					WechatArticle: &core.WechatArticle{Headline: "防晒的三个误区", Sections: []core.ArticleSection{
+						{Content: "开头没有小标题。"},
+						{Heading: "误区一", Content: "阴天不用防晒。"},
+					}},
+				}},
+				{ID: "tiktok_script1", Type: "tiktok_script", Index: 1, Action: "tiktok_script", Round: 4, CreatedAt: at(7), Deliverable: &core.Deliverable{
+					Kind:         core.DeliverableTiktokScript,
+					TiktokScript: &core.TiktokScript{Hook: "你还在每天补涂吗？", Body: "试试这一支。", Closing: "关注我，下期讲卸妆。"},
+				}},
+			},
+			NoteSeq: map[string]int{"websearch": 1, "profile": 1, "insight": 1, "trend": 1, "xhs_post": 1, "wechat_article": 1, "tiktok_script": 1},
+			Tasks:   []string{"写一篇通勤防晒的小红书帖子"},
+			Tactics: []core.Tactic{
+				{Round: 1, Action: "websearch", Memo: "先搜索防晒霜测评", Timestamp: at(1)},
+				{Round: 2, Action: "insight", Memo: "从画像推导洞察", Timestamp: at(3)},
+				{Round: 3, Action: "xhs_post", Memo: "按洞察写帖子", Timestamp: at(5)},
+				{Round: 4, Action: "wechat_article", Memo: "补充长文和口播稿", Timestamp: at(6)},
+			},
+			Rounds: 4,
+		},
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	report := BuildReport(newGoldenSession())
+	report.ExportedAt = time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []Format{FormatMarkdown, FormatJSON, FormatHTML} {
+		t.Run(string(format), func(t *testing.T) {
+			got, err := report.Render(format)
+			if err != nil {
+				t.Fatalf("渲染报告失败: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "session"+format.Extension())
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("更新 golden 文件失败: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("读取 golden 文件失败: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("导出内容与 %s 不一致（用 go test ./export -update 更新），实际:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	session := newGoldenSession()
+	path := filepath.Join(t.TempDir(), DefaultFileName(session.ID, FormatHTML))
+	if err := WriteFile(session, FormatHTML, path); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取导出文件失败: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "session.html"))
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败: %v", err)
+	}
+	// HTML 报告不包含导出时间，写入的文件与 golden 完全一致
+	if !bytes.Equal(got, want) {
+		t.Errorf("导出文件与 golden 不一致:\n%s", got)
+	}
+
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("不支持的格式应当报错")
+	}
+}
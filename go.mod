@@ -8,9 +8,10 @@ require (
 	github.com/cloudwego/eino v0.4.0
 	github.com/fatih/color v1.16.0
 	github.com/google/generative-ai-go v0.20.1
-	github.com/sashabaranov/go-openai v1.17.9
+	github.com/sashabaranov/go-openai v1.35.6
 	github.com/spf13/cobra v1.8.0
 	google.golang.org/api v0.244.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -75,5 +76,4 @@ require (
 	google.golang.org/grpc v1.74.2 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"loomi2.0/cmd"
+	"loomi2.0/config"
 	"loomi2.0/core"
 	"loomi2.0/models"
 	"loomi2.0/utils"
@@ -27,9 +28,18 @@ func main() {
 支持多种AI模型，提供智能对话和任务编排功能`,
 	}
 
+	// 加载配置文件和环境变量，各子命令按需校验
+	var configPath string
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "配置文件路径（默认依次查找 ./loomi.yaml、~/.loomi/config.yaml）")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return config.InitConfig(configPath)
+	}
+
 	// 添加子命令
 	rootCmd.AddCommand(cmd.StartCmd())
 	rootCmd.AddCommand(cmd.VersionCmd())
+	rootCmd.AddCommand(cmd.SessionsCmd())
+	rootCmd.AddCommand(cmd.ExportCmd())
 
 	// 设置默认命令
 	rootCmd.SetHelpCommand(&cobra.Command{
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"loomi2.0/config"
+)
+
+// ErrBudgetExceeded 超出花费预算，具体信息见 *BudgetError
+var ErrBudgetExceeded = errors.New("超出预算")
+
+// 预算范围
+const (
+	BudgetScopeSession = "session"
+	BudgetScopeTask    = "task"
+)
+
+// budgetScopeNames 预算范围的显示名称
+var budgetScopeNames = map[string]string{
+	BudgetScopeSession: "会话",
+	BudgetScopeTask:    "任务",
+}
+
+// BudgetError 超出预算时模型调用返回的错误
+type BudgetError struct {
+	Status BudgetStatus // 超出时的预算使用情况，Status.Scope 为 session 或 task
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s预算已用尽（%s）", e.Status.ScopeName(), e.Status)
+}
+
+// Unwrap 使 errors.Is(err, ErrBudgetExceeded) 成立
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// BudgetStatus 预算使用情况
+type BudgetStatus struct {
+	Scope  string
+	Limits config.BudgetLimits
+	Used   UsageSummary
+}
+
+// ScopeName 预算范围的显示名称：会话或任务
+func (s BudgetStatus) ScopeName() string {
+	return budgetScopeNames[s.Scope]
+}
+
+// Ratio 最接近上限的一项已用比例，没有设置上限时为 0
+func (s BudgetStatus) Ratio() float64 {
+	ratio := 0.0
+	if s.Limits.MaxCost > 0 {
+		ratio = maxFloat(ratio, s.Used.Cost/s.Limits.MaxCost)
+	}
+	if s.Limits.MaxTokens > 0 {
+		ratio = maxFloat(ratio, float64(s.Used.totalTokens())/float64(s.Limits.MaxTokens))
+	}
+	if s.Limits.MaxCalls > 0 {
+		ratio = maxFloat(ratio, float64(s.Used.Calls)/float64(s.Limits.MaxCalls))
+	}
+	return ratio
+}
+
+// Exceeded 是否已用尽任一上限
+func (s BudgetStatus) Exceeded() bool {
+	return s.Limits.Limited() && s.Ratio() >= 1
+}
+
+// Overrun 是否有任一项超过上限；刚好用满不算超过，本次调用仍然有效
+func (s BudgetStatus) Overrun() bool {
+	return (s.Limits.MaxCost > 0 && s.Used.Cost > s.Limits.MaxCost) ||
+		(s.Limits.MaxTokens > 0 && s.Used.totalTokens() > s.Limits.MaxTokens) ||
+		(s.Limits.MaxCalls > 0 && s.Used.Calls > s.Limits.MaxCalls)
+}
+
+// String 形如 "费用 $0.4200/$0.5000 · 调用 12/20"，只列出设置了上限的项
+func (s BudgetStatus) String() string {
+	var parts []string
+	if s.Limits.MaxCost > 0 {
+		parts = append(parts, fmt.Sprintf("费用 $%.4f/$%.4f", s.Used.Cost, s.Limits.MaxCost))
+	}
+	if s.Limits.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("token %d/%d", s.Used.totalTokens(), s.Limits.MaxTokens))
+	}
+	if s.Limits.MaxCalls > 0 {
+		parts = append(parts, fmt.Sprintf("调用 %d/%d", s.Used.Calls, s.Limits.MaxCalls))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// totalTokens 计入预算的 token：输入、输出和思考 token 合计
+func (s UsageSummary) totalTokens() int {
+	return s.InputTokens + s.OutputTokens + s.ThinkingTokens
+}
+
+// Budget 一个范围内的花费预算，只统计创建之后的调用
+type Budget struct {
+	scope  string
+	limits config.BudgetLimits
+	used   UsageSummary
+	mu     sync.RWMutex
+}
+
+// NewBudget 创建预算
+func NewBudget(scope string, limits config.BudgetLimits) *Budget {
+	return &Budget{scope: scope, limits: limits}
+}
+
+// add 计入一次调用
+func (b *Budget) add(record UsageRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used.add(record)
+}
+
+// Status 获取预算使用情况
+func (b *Budget) Status() BudgetStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return BudgetStatus{Scope: b.scope, Limits: b.limits, Used: b.used}
+}
+
+// Check 已用尽任一上限时返回 *BudgetError
+func (b *Budget) Check() error {
+	if status := b.Status(); status.Exceeded() {
+		return &BudgetError{Status: status}
+	}
+	return nil
+}
+
+type budgetKey struct{}
+
+// WithBudget 返回携带预算的 ctx，经过该 ctx 的模型调用计入预算并在用尽时被拒绝
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetFromContext 获取 ctx 中的预算，没有时返回 nil
+func BudgetFromContext(ctx context.Context) *Budget {
+	budget, _ := ctx.Value(budgetKey{}).(*Budget)
+	return budget
+}
+
+// budgets 会话预算和 ctx 中的任务预算
+func (m *ModelManager) budgets(ctx context.Context) []*Budget {
+	m.mu.RLock()
+	budgets := []*Budget{m.sessionBudget}
+	m.mu.RUnlock()
+	if budget := BudgetFromContext(ctx); budget != nil {
+		budgets = append(budgets, budget)
+	}
+	return budgets
+}
+
+// SetBudget 设置预算配置，会话预算从此刻重新统计
+func (m *ModelManager) SetBudget(cfg config.BudgetConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budget = cfg
+	m.sessionBudget = NewBudget(BudgetScopeSession, cfg.Session)
+}
+
+// NewTaskBudget 按配置为一个编排任务创建预算
+func (m *ModelManager) NewTaskBudget() *Budget {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return NewBudget(BudgetScopeTask, m.budget.Task)
+}
+
+// CheckBudget 调用模型前检查会话预算和 ctx 中的任务预算，已用尽时返回 *BudgetError
+func (m *ModelManager) CheckBudget(ctx context.Context) error {
+	for _, budget := range m.budgets(ctx) {
+		if err := budget.Check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOverrun 调用模型后检查：记账后任一预算超过上限时返回 *BudgetError
+func (m *ModelManager) checkOverrun(ctx context.Context) error {
+	for _, budget := range m.budgets(ctx) {
+		if status := budget.Status(); status.Overrun() {
+			return &BudgetError{Status: status}
+		}
+	}
+	return nil
+}
+
+// BudgetStatuses 设置了上限的预算的使用情况
+func (m *ModelManager) BudgetStatuses(ctx context.Context) []BudgetStatus {
+	var statuses []BudgetStatus
+	for _, budget := range m.budgets(ctx) {
+		if status := budget.Status(); status.Limits.Limited() {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// BudgetNearLimit 任一预算的已用比例达到收尾阈值时返回最紧张的预算
+func (m *ModelManager) BudgetNearLimit(ctx context.Context) (BudgetStatus, bool) {
+	m.mu.RLock()
+	threshold := m.budget.WrapUpRatio
+	m.mu.RUnlock()
+
+	var tightest BudgetStatus
+	for _, status := range m.BudgetStatuses(ctx) {
+		if status.Ratio() > tightest.Ratio() {
+			tightest = status
+		}
+	}
+	return tightest, threshold > 0 && tightest.Limits.Limited() && tightest.Ratio() >= threshold
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"loomi2.0/config"
+)
+
+func TestBudgetStatus(t *testing.T) {
+	status := BudgetStatus{
+		Scope:  BudgetScopeTask,
+		Limits: config.BudgetLimits{MaxCost: 1, MaxCalls: 10},
+		Used:   UsageSummary{Calls: 9, Cost: 0.5},
+	}
+	if status.Ratio() != 0.9 || status.Exceeded() {
+		t.Errorf("预算比例错误: %v", status.Ratio())
+	}
+
+	status.Used.Calls = 10
+	var err error = &BudgetError{Status: status}
+	if !status.Exceeded() || !errors.Is(fmt.Errorf("第1轮编排调用失败: %w", err), ErrBudgetExceeded) {
+		t.Errorf("超出预算的错误应当可以用 errors.Is 识别: %v", err)
+	}
+
+	if status.Overrun() {
+		t.Error("刚好用满上限不算超过")
+	}
+	status.Used.Calls = 11
+	if !status.Overrun() {
+		t.Error("超过上限应当算超过")
+	}
+}
+
+func TestBudgetCheckedAfterCall(t *testing.T) {
+	t.Setenv("LOOMI_DEEPSEEK_API_KEY", "test-key")
+	if err := InitModelManager(); err != nil {
+		t.Fatalf("初始化模型管理器失败: %v", err)
+	}
+	manager := GetModelManager()
+
+	fake, _ := NewFakeProvider(config.ProviderConfig{Name: "fake-budget", Type: config.ProviderTypeFake}, &FakeScript{
+		Rules: []*FakeRule{
+			{Name: "short", User: "短", Responses: []string{"短应答"}, InputTokens: 10, OutputTokens: 10},
+			{Name: "long", User: "长", Responses: []string{"长应答"}, InputTokens: 10, OutputTokens: 30},
+		},
+	})
+	manager.RegisterProvider(fake)
+
+	previous := GetCurrentModelName()
+	manager.SetBudget(config.BudgetConfig{Session: config.BudgetLimits{MaxTokens: 50}})
+	defer func() {
+		manager.SetBudget(config.GetConfig().Budget)
+		if previous != "" {
+			manager.SetCurrentProvider(previous)
+		}
+	}()
+	if err := manager.SetCurrentProvider("fake-budget"); err != nil {
+		t.Fatalf("设置当前模型失败: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := manager.Call(ctx, "系统", "短", nil); err != nil {
+		t.Fatalf("预算内的调用不应失败: %v", err)
+	}
+
+	// 越过上限的调用照常计账并返回结果，同时报告超出预算
+	result, err := manager.Call(ctx, "系统", "长", nil)
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Status.Scope != BudgetScopeSession || result.Output != "长应答" {
+		t.Fatalf("越过上限的调用应当返回结果和 *BudgetError，实际 %+v (%v)", result, err)
+	}
+	if used := budgetErr.Status.Used.totalTokens(); used != 60 {
+		t.Errorf("越过上限的调用应当计入预算，实际 %d", used)
+	}
+
+	// 之后的调用在调用前被拒绝
+	if _, err := manager.Call(ctx, "系统", "短", nil); !errors.Is(err, ErrBudgetExceeded) || len(fake.Calls()) != 2 {
+		t.Errorf("超出预算后不应再调用模型: %v，调用 %d 次", err, len(fake.Calls()))
+	}
+
+	// 重置统计后会话预算重新统计
+	manager.ResetStats()
+	if _, err := manager.Call(ctx, "系统", "短", nil); err != nil {
+		t.Errorf("重置统计后应当可以继续调用: %v", err)
+	}
+}
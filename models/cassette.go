@@ -0,0 +1,236 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/cassette"
+	"loomi2.0/config"
+)
+
+// cassetteMessages 转换为磁带中的消息格式
+func cassetteMessages(input []*schema.Message) []cassette.Message {
+	messages := make([]cassette.Message, 0, len(input))
+	for _, msg := range input {
+		messages = append(messages, cassette.Message{Role: string(msg.Role), Content: msg.Content})
+	}
+	return messages
+}
+
+// callWithMessages 按 system/user 提示词构建消息并调用 Generate
+func callWithMessages(ctx context.Context, chatModel model.BaseChatModel, systemPrompt, userPrompt string) (string, error) {
+	messages := []*schema.Message{}
+	if systemPrompt != "" {
+		messages = append(messages, schema.SystemMessage(systemPrompt))
+	}
+	messages = append(messages, schema.UserMessage(userPrompt))
+
+	response, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("调用模型失败: %w", err)
+	}
+	return response.Content, nil
+}
+
+// RecordingProvider 把模型调用录制到磁带的提供商包装，调用本身仍交给被包装的提供商
+type RecordingProvider struct {
+	ModelProvider
+	cassette *cassette.Cassette
+}
+
+// NewRecordingProvider 创建录制包装
+func NewRecordingProvider(provider ModelProvider, c *cassette.Cassette) *RecordingProvider {
+	return &RecordingProvider{ModelProvider: provider, cassette: c}
+}
+
+// record 录制一次模型调用；提供商的错误保留类别和 Retry-After，成功的调用保留用量
+func (p *RecordingProvider) record(method string, messages []cassette.Message, output string, usage *UsageRecord, callErr error) error {
+	in := &cassette.Interaction{
+		Kind:     cassette.KindModel,
+		Name:     p.Name(),
+		Method:   method,
+		Key:      cassette.ModelKey(messages),
+		Messages: messages,
+		Output:   output,
+	}
+	if callErr != nil {
+		in.Error = callErr.Error()
+		var providerErr *ProviderError
+		if errors.As(callErr, &providerErr) {
+			in.ErrorInfo = &cassette.ErrorInfo{
+				Provider:   providerErr.Provider,
+				Kind:       string(providerErr.Kind),
+				StatusCode: providerErr.StatusCode,
+				RetryAfter: providerErr.RetryAfter,
+			}
+			if providerErr.Err != nil {
+				in.ErrorInfo.Cause = providerErr.Err.Error()
+			}
+		}
+	}
+	if usage != nil {
+		in.Usage = &cassette.Usage{
+			InputTokens:    usage.InputTokens,
+			OutputTokens:   usage.OutputTokens,
+			ThinkingTokens: usage.ThinkingTokens,
+			CachedTokens:   usage.CachedTokens,
+			Cost:           usage.Cost,
+			Estimated:      usage.Estimated,
+		}
+	}
+	return p.cassette.Record(in)
+}
+
+// Generate 调用被包装的提供商并录制结果
+func (p *RecordingProvider) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var usage *UsageRecord
+	ctx = withUsageObserver(ctx, func(record UsageRecord) { usage = &record })
+
+	msg, err := p.ModelProvider.Generate(ctx, input, opts...)
+	output := ""
+	if msg != nil {
+		output = msg.Content
+	}
+	if recordErr := p.record("generate", cassetteMessages(input), output, usage, err); recordErr != nil {
+		return nil, recordErr
+	}
+	return msg, err
+}
+
+// Stream 转发被包装提供商的流，结束后把完整输出录制到磁带
+func (p *RecordingProvider) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	messages := cassetteMessages(input)
+	// 被包装的提供商在流结束、发送 EOF 之前记账
+	var usage *UsageRecord
+	ctx = withUsageObserver(ctx, func(record UsageRecord) { usage = &record })
+
+	stream, err := p.ModelProvider.Stream(ctx, input, opts...)
+	if err != nil {
+		if recordErr := p.record("stream", messages, "", nil, err); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](5)
+	go func() {
+		defer writer.Close()
+		defer stream.Close()
+
+		var content strings.Builder
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				err = nil
+			}
+			if chunk != nil {
+				content.WriteString(chunk.Content)
+			}
+			if err != nil || chunk == nil {
+				if recordErr := p.record("stream", messages, content.String(), usage, err); recordErr != nil {
+					err = recordErr
+				}
+				if err != nil {
+					writer.Send(nil, err)
+				}
+				return
+			}
+			writer.Send(chunk, nil)
+		}
+	}()
+	return reader, nil
+}
+
+// CallLLM 调用LLM（兼容原有接口），经过录制
+func (p *RecordingProvider) CallLLM(ctx context.Context, systemPrompt, userPrompt string, options map[string]interface{}) (string, error) {
+	return callWithMessages(ctx, p, systemPrompt, userPrompt)
+}
+
+// ReplayProvider 从磁带回放模型调用的提供商，不访问任何网络
+type ReplayProvider struct {
+	*BaseProvider
+	cfg      config.ProviderConfig
+	cassette *cassette.Cassette
+}
+
+// NewReplayProvider 按配置加载磁带并创建回放提供商
+func NewReplayProvider(cfg config.ProviderConfig) (*ReplayProvider, error) {
+	if cfg.Cassette == "" {
+		return nil, fmt.Errorf("提供商 %s 缺少 cassette", cfg.Name)
+	}
+	c, err := cassette.Load(cfg.Cassette)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+
+	baseProvider := NewBaseProvider(cfg.Name, cfg.DisplayName, nil)
+	provider := &ReplayProvider{
+		BaseProvider: baseProvider,
+		cfg:          cfg,
+		cassette:     c,
+	}
+	baseProvider.client = provider
+	return provider, nil
+}
+
+// Generate 返回磁带中与请求匹配的响应，并按录制的用量记账
+func (p *ReplayProvider) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	started := time.Now()
+	in, err := p.cassette.Next(cassette.ModelKey(cassetteMessages(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s 回放失败: %v", p.cfg.DisplayName, err)
+	}
+	if in.Error != "" {
+		return nil, replayError(in)
+	}
+
+	record := UsageRecord{Model: p.cfg.Model}
+	if in.Usage != nil {
+		record.InputTokens = in.Usage.InputTokens
+		record.OutputTokens = in.Usage.OutputTokens
+		record.ThinkingTokens = in.Usage.ThinkingTokens
+		record.CachedTokens = in.Usage.CachedTokens
+		record.Cost = in.Usage.Cost
+		record.Estimated = in.Usage.Estimated
+	} else {
+		// 没有录制用量的旧磁带按本地估算记账
+		record.InputTokens = EstimateMessagesTokens(input)
+		record.OutputTokens = EstimateTokens(in.Output)
+		record.Estimated = true
+	}
+	recordUsage(ctx, p, started, record)
+	return schema.AssistantMessage(in.Output, nil), nil
+}
+
+// replayError 重建录制的错误；提供商的错误恢复为 *ProviderError，重试和换用后备提供商的判断与录制时一致
+func replayError(in *cassette.Interaction) error {
+	info := in.ErrorInfo
+	if info == nil {
+		return errors.New(in.Error)
+	}
+	return &ProviderError{
+		Provider:   info.Provider,
+		Kind:       ErrorKind(info.Kind),
+		StatusCode: info.StatusCode,
+		RetryAfter: info.RetryAfter,
+		Err:        errors.New(info.Cause),
+	}
+}
+
+// Stream 回放时把响应按行拆成多个分片返回
+func (p *ReplayProvider) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := p.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return lineStream(msg.Content), nil
+}
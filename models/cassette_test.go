@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/cassette"
+	"loomi2.0/config"
+)
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	tape, err := cassette.New(path)
+	if err != nil {
+		t.Fatalf("创建磁带失败: %v", err)
+	}
+
+	fake, err := NewFakeProvider(config.ProviderConfig{Name: "fake", Type: config.ProviderTypeFake}, &FakeScript{
+		Queue: []string{"第一次", "第二次"},
+	})
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+	recorder := NewRecordingProvider(fake, tape)
+
+	ctx := context.Background()
+	var recorded []string
+	for _, prompt := range []string{"[10-16 09:30] 任务", "[10-16 09:31] 任务"} {
+		output, err := recorder.CallLLM(ctx, "系统", prompt, nil)
+		if err != nil {
+			t.Fatalf("录制调用失败: %v", err)
+		}
+		recorded = append(recorded, output)
+	}
+
+	replay, err := NewReplayProvider(config.ProviderConfig{Name: "replay", Type: config.ProviderTypeReplay, Cassette: path})
+	if err != nil {
+		t.Fatalf("创建回放提供商失败: %v", err)
+	}
+	// 时间戳不同也能匹配到同一请求，并按录制顺序返回
+	for _, want := range recorded {
+		if got, err := replay.CallLLM(ctx, "系统", "[10-17 21:00] 任务", nil); err != nil || got != want {
+			t.Errorf("回放应答错误，期望 %q，实际 %q (%v)", want, got, err)
+		}
+	}
+	if _, err := replay.CallLLM(ctx, "系统", "没有录制的请求", nil); err == nil {
+		t.Error("没有录制的请求应该返回错误")
+	}
+}
+
+func TestCassetteStreamRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.json")
+	tape, err := cassette.New(path)
+	if err != nil {
+		t.Fatalf("创建磁带失败: %v", err)
+	}
+	fake, err := NewFakeProvider(config.ProviderConfig{Name: "fake", Type: config.ProviderTypeFake}, &FakeScript{
+		Rules: []*FakeRule{
+			{Name: "down", User: "失败", Error: "服务不可用"},
+			{Name: "lines", User: "分行", Responses: []string{"第一行\n第二行\n第三行"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+	recorder := NewRecordingProvider(fake, tape)
+
+	ctx := context.Background()
+	recorded, err := readStream(recorder.Stream(ctx, []*schema.Message{schema.SystemMessage("系统"), schema.UserMessage("分行")}))
+	if err != nil {
+		t.Fatalf("录制流式调用失败: %v", err)
+	}
+	if _, err := recorder.Generate(ctx, []*schema.Message{schema.SystemMessage("系统"), schema.UserMessage("失败")}); err == nil {
+		t.Fatal("注入的错误应当返回")
+	}
+
+	// 重新从文件加载，确认录制的内容都已写盘
+	replay, err := NewReplayProvider(config.ProviderConfig{Name: "replay", Type: config.ProviderTypeReplay, Cassette: path})
+	if err != nil {
+		t.Fatalf("创建回放提供商失败: %v", err)
+	}
+	replayed, err := readStream(replay.Stream(ctx, []*schema.Message{schema.SystemMessage("系统"), schema.UserMessage("分行")}))
+	if err != nil || replayed != recorded {
+		t.Errorf("流式回放应当得到录制的完整输出，期望 %q，实际 %q (%v)", recorded, replayed, err)
+	}
+	if _, err := replay.Generate(ctx, []*schema.Message{schema.SystemMessage("系统"), schema.UserMessage("失败")}); err == nil || !strings.Contains(err.Error(), "服务不可用") {
+		t.Errorf("回放应当返回录制的错误，实际 %v", err)
+	}
+}
+
+// readStream 读完流并拼接所有分片
+func readStream(stream *schema.StreamReader[*schema.Message], err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return content.String(), nil
+		}
+		if err != nil {
+			return content.String(), err
+		}
+		content.WriteString(chunk.Content)
+	}
+}
+
+func TestCassetteReplaysRetriedCall(t *testing.T) {
+	t.Setenv("LOOMI_DEEPSEEK_API_KEY", "test-key")
+	if err := InitModelManager(); err != nil {
+		t.Fatalf("初始化模型管理器失败: %v", err)
+	}
+	ledger := GetModelManager().Ledger()
+
+	path := filepath.Join(t.TempDir(), "retry.json")
+	tape, err := cassette.New(path)
+	if err != nil {
+		t.Fatalf("创建磁带失败: %v", err)
+	}
+	fake, err := NewFakeProvider(config.ProviderConfig{Name: "fake", Type: config.ProviderTypeFake, Pricing: config.Pricing{InputPer1M: 1, OutputPer1M: 2}}, &FakeScript{
+		Rules: []*FakeRule{
+			{Name: "rate-limited", Error: "429", ErrorKind: ErrorKindRateLimited, RetryAfter: 5 * time.Millisecond, Times: 1},
+			{Name: "ok", Responses: []string{"成功"}, InputTokens: 120, OutputTokens: 30},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+
+	var retries []time.Duration
+	policy := RetryPolicy{MaxAttempts: 2, MaxDelay: time.Second, OnRetry: func(attempt int, delay time.Duration, err error) {
+		retries = append(retries, delay)
+	}}
+	call := func(provider ModelProvider) (string, error) {
+		var output string
+		err := policy.Do(context.Background(), func() error {
+			var err error
+			output, err = provider.CallLLM(context.Background(), "系统", "任务", nil)
+			return err
+		})
+		return output, err
+	}
+
+	// 录制：第一次限流，重试后成功，两次交互的 key 相同
+	if output, err := call(NewRecordingProvider(fake, tape)); err != nil || output != "成功" {
+		t.Fatalf("录制时重试后应当成功，实际 %q (%v)", output, err)
+	}
+	recorded := ledger.Records()[len(ledger.Records())-1]
+
+	// 回放：恢复限流错误的类别和 Retry-After，同样重试一次后成功，并按录制的用量记账
+	retries = nil
+	replay, err := NewReplayProvider(config.ProviderConfig{Name: "replay", Type: config.ProviderTypeReplay, Cassette: path})
+	if err != nil {
+		t.Fatalf("创建回放提供商失败: %v", err)
+	}
+	if output, err := call(replay); err != nil || output != "成功" {
+		t.Fatalf("回放时重试后应当成功，实际 %q (%v)", output, err)
+	}
+	if len(retries) != 1 || retries[0] < 5*time.Millisecond {
+		t.Errorf("回放时应当按录制的 Retry-After 重试一次，实际 %v", retries)
+	}
+	replayed := ledger.Records()[len(ledger.Records())-1]
+	if replayed.Provider != "replay" || replayed.InputTokens != 120 || replayed.OutputTokens != 30 || replayed.Cost != recorded.Cost || recorded.Cost == 0 {
+		t.Errorf("回放应当按录制的用量记账，录制 %+v，回放 %+v", recorded, replayed)
+	}
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"loomi2.0/config"
+)
+
+// ErrorKind 模型提供商错误的类别
+type ErrorKind string
+
+const (
+	ErrorKindRateLimited     ErrorKind = "rate_limited"     // 请求过于频繁，可重试
+	ErrorKindAuth            ErrorKind = "auth"             // 密钥无效、无权限或额度不足
+	ErrorKindContextTooLong  ErrorKind = "context_too_long" // 输入超出模型的上下文长度
+	ErrorKindContentFiltered ErrorKind = "content_filtered" // 输入或输出被安全策略拦截
+	ErrorKindServer          ErrorKind = "server_error"     // 服务端错误，可重试
+	ErrorKindTimeout         ErrorKind = "timeout"          // 请求超时，可重试
+	ErrorKindUnknown         ErrorKind = "unknown"
+)
+
+// errorKindNames 错误类别的显示名称
+var errorKindNames = map[ErrorKind]string{
+	ErrorKindRateLimited:     "请求过于频繁",
+	ErrorKindAuth:            "鉴权失败",
+	ErrorKindContextTooLong:  "输入超出上下文长度",
+	ErrorKindContentFiltered: "内容被安全策略拦截",
+	ErrorKindServer:          "服务端错误",
+	ErrorKindTimeout:         "请求超时",
+	ErrorKindUnknown:         "调用失败",
+}
+
+// Retryable 该类错误是否值得重试
+func (k ErrorKind) Retryable() bool {
+	return k == ErrorKindRateLimited || k == ErrorKindServer || k == ErrorKindTimeout
+}
+
+// ProviderError 模型提供商返回的错误，各 SDK 的错误都转换为该类型
+type ProviderError struct {
+	Provider   string        // 提供商名称
+	Kind       ErrorKind     // 错误类别
+	StatusCode int           // HTTP 状态码，没有时为 0
+	RetryAfter time.Duration // 服务端要求的重试等待时间，没有时为 0
+	Err        error         // SDK 返回的原始错误
+}
+
+func (e *ProviderError) Error() string {
+	status := ""
+	if e.StatusCode > 0 {
+		status = fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s%s: %v", e.Provider, errorKindNames[e.Kind], status, e.Err)
+}
+
+// Unwrap 返回 SDK 的原始错误
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf 获取错误的类别，不是 ProviderError 时返回 ErrorKindUnknown
+func ErrorKindOf(err error) ErrorKind {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Kind
+	}
+	return ErrorKindUnknown
+}
+
+// IsRetryable 错误是否值得重试；流式输出已经开始后中断的调用不重试，以免重复输出
+func IsRetryable(err error) bool {
+	var interrupted *streamInterruptedError
+	if errors.As(err, &interrupted) {
+		return false
+	}
+	return ErrorKindOf(err).Retryable()
+}
+
+// streamInterruptedError 流式输出已经开始后中断
+type streamInterruptedError struct {
+	err error
+}
+
+func (e *streamInterruptedError) Error() string {
+	return fmt.Sprintf("流式输出中断: %v", e.err)
+}
+
+func (e *streamInterruptedError) Unwrap() error {
+	return e.err
+}
+
+// classifyError 按 HTTP 状态码和错误信息归类
+func classifyError(statusCode int, code, message string) ErrorKind {
+	text := strings.ToLower(code + " " + message)
+	switch {
+	case containsAny(text, "context_length_exceeded", "maximum context length", "context length", "too many tokens", "exceeds the maximum number of tokens", "input is too long"):
+		return ErrorKindContextTooLong
+	case containsAny(text, "content_filter", "content_policy", "content exists risk", "sensitivecontent", "safety"):
+		return ErrorKindContentFiltered
+	case containsAny(text, "insufficient_quota", "invalid_api_key", "api key not valid", "authentication"):
+		// 额度不足同样返回 429，但重试没有意义
+		return ErrorKindAuth
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || statusCode == http.StatusPaymentRequired:
+		return ErrorKindAuth
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return ErrorKindTimeout
+	case statusCode == http.StatusRequestEntityTooLarge:
+		return ErrorKindContextTooLong
+	case statusCode >= 500:
+		return ErrorKindServer
+	}
+	return ErrorKindUnknown
+}
+
+// classifyTransportError 归类没有 HTTP 响应的错误：超时或网络错误
+func classifyTransportError(err error) (ErrorKind, bool) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout, true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorKindTimeout, true
+		}
+		// 连接被重置、拒绝等网络错误按服务端错误重试
+		return ErrorKindServer, true
+	}
+	return ErrorKindUnknown, false
+}
+
+func containsAny(text string, substrings ...string) bool {
+	for _, s := range substrings {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryPolicy 模型调用的重试策略：可重试的错误按指数退避加随机抖动重试，服务端给出 Retry-After 时至少等待该时长
+type RetryPolicy struct {
+	MaxAttempts int                                               // 最多调用次数，包含第一次，1 表示不重试
+	BaseDelay   time.Duration                                     // 第一次重试前的基础等待时间，之后每次翻倍
+	MaxDelay    time.Duration                                     // 单次等待的上限，Retry-After 超过该值时不再重试
+	OnRetry     func(attempt int, delay time.Duration, err error) // 每次重试前调用，attempt 为即将进行的第几次调用
+}
+
+// NewRetryPolicy 按配置创建重试策略
+func NewRetryPolicy(cfg config.RetryConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+}
+
+// Delay 第 attempt 次调用失败后的等待时间，返回 false 表示不应再重试
+func (p RetryPolicy) Delay(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !IsRetryable(err) {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// 等量抖动：在 [delay/2, delay) 之间随机，避免多个请求同时重试
+	if delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.RetryAfter > delay {
+		if p.MaxDelay > 0 && providerErr.RetryAfter > p.MaxDelay {
+			return 0, false
+		}
+		delay = providerErr.RetryAfter
+	}
+	return delay, true
+}
+
+// Do 调用 call，失败且可重试时等待后重试；ctx 结束时返回最后一次的错误
+func (p RetryPolicy) Do(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil {
+			return nil
+		}
+		delay, retry := p.Delay(attempt, err)
+		if !retry {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt+1, delay, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+	"loomi2.0/config"
+)
+
+// FakeRule 假模型的应答规则：system/user 正则都匹配时命中
+type FakeRule struct {
+	Name         string        `yaml:"name"`
+	System       string        `yaml:"system"`        // 匹配 system 提示词的正则，为空时不限制
+	User         string        `yaml:"user"`          // 匹配用户提示词的正则，为空时不限制
+	Responses    []string      `yaml:"responses"`     // 命中时按顺序轮流返回
+	Error        string        `yaml:"error"`         // 非空时返回该错误而不是应答
+	ErrorKind    ErrorKind     `yaml:"error_kind"`    // 错误的类别，例如 rate_limited，默认 unknown
+	RetryAfter   time.Duration `yaml:"retry_after"`   // 模拟服务端返回的 Retry-After
+	Times        int           `yaml:"times"`         // 最多命中次数，0 表示不限，用于模拟前几次调用失败
+	Latency      time.Duration `yaml:"latency"`       // 覆盖脚本的默认延迟
+	InputTokens  int           `yaml:"input_tokens"`  // 模拟的输入 token 数，0 时按提示词长度估算
+	OutputTokens int           `yaml:"output_tokens"` // 模拟的输出 token 数，0 时按应答长度估算
+
+	system *regexp.Regexp
+	user   *regexp.Regexp
+	hits   int
+}
+
+// FakeScript 假模型的应答脚本：先按规则匹配，没有命中时依次取队列，队列用完后返回默认应答
+type FakeScript struct {
+	Latency time.Duration `yaml:"latency"`
+	Rules   []*FakeRule   `yaml:"rules"`
+	Queue   []string      `yaml:"queue"`
+	Default string        `yaml:"default"`
+}
+
+// FakeCall 假模型收到的一次调用
+type FakeCall struct {
+	System string
+	User   string
+	Rule   string // 命中的规则名称，来自队列或默认应答时为空
+	Output string
+	Err    error
+}
+
+// LoadFakeScript 从 YAML 文件加载应答脚本
+func LoadFakeScript(path string) (*FakeScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 fake 脚本失败: %v", err)
+	}
+	script := &FakeScript{}
+	if err := yaml.Unmarshal(data, script); err != nil {
+		return nil, fmt.Errorf("解析 fake 脚本 %s 失败: %v", path, err)
+	}
+	return script, nil
+}
+
+// compile 编译规则中的正则
+func (s *FakeScript) compile() error {
+	for i, rule := range s.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule%d", i+1)
+		}
+		if len(rule.Responses) == 0 && rule.Error == "" {
+			return fmt.Errorf("fake 规则 %s 既没有 responses 也没有 error", rule.Name)
+		}
+		if rule.ErrorKind == "" {
+			rule.ErrorKind = ErrorKindUnknown
+		}
+		if _, ok := errorKindNames[rule.ErrorKind]; !ok {
+			return fmt.Errorf("fake 规则 %s 的 error_kind 无效: %s", rule.Name, rule.ErrorKind)
+		}
+		var err error
+		if rule.System != "" {
+			if rule.system, err = regexp.Compile(rule.System); err != nil {
+				return fmt.Errorf("fake 规则 %s 的 system 正则无效: %v", rule.Name, err)
+			}
+		}
+		if rule.User != "" {
+			if rule.user, err = regexp.Compile(rule.User); err != nil {
+				return fmt.Errorf("fake 规则 %s 的 user 正则无效: %v", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *FakeRule) matches(system, user string) bool {
+	if r.Times > 0 && r.hits >= r.Times {
+		return false
+	}
+	if r.system != nil && !r.system.MatchString(system) {
+		return false
+	}
+	if r.user != nil && !r.user.MatchString(user) {
+		return false
+	}
+	return true
+}
+
+// FakeProvider 按脚本应答的假模型提供商，用于离线演示和测试，不访问任何网络
+type FakeProvider struct {
+	*BaseProvider
+	cfg    config.ProviderConfig
+	script *FakeScript
+	mu     sync.Mutex
+	queue  int
+	calls  []FakeCall
+}
+
+// NewFakeProvider 创建假模型提供商；script 为空时加载 cfg.Script，也没有配置时使用内置的演示脚本
+func NewFakeProvider(cfg config.ProviderConfig, script *FakeScript) (*FakeProvider, error) {
+	if script == nil {
+		var err error
+		if cfg.Script != "" {
+			if script, err = LoadFakeScript(cfg.Script); err != nil {
+				return nil, err
+			}
+		} else {
+			script = DefaultFakeScript()
+		}
+	}
+	if err := script.compile(); err != nil {
+		return nil, err
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+
+	baseProvider := NewBaseProvider(cfg.Name, cfg.DisplayName, nil)
+	provider := &FakeProvider{
+		BaseProvider: baseProvider,
+		cfg:          cfg,
+		script:       script,
+	}
+	baseProvider.client = provider
+	return provider, nil
+}
+
+// CalculateCost 计算费用，价格来自配置，默认免费
+func (p *FakeProvider) CalculateCost(inputTokens, outputTokens, thinkingTokens int) float64 {
+	inputCost := float64(inputTokens) / 1_000_000 * p.cfg.Pricing.InputPer1M
+	outputCost := float64(outputTokens+thinkingTokens) / 1_000_000 * p.cfg.Pricing.OutputPer1M
+	return inputCost + outputCost
+}
+
+// Calls 返回已收到的调用记录
+func (p *FakeProvider) Calls() []FakeCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeCall(nil), p.calls...)
+}
+
+// respond 按脚本选出应答，返回应答、命中的规则和延迟
+func (p *FakeProvider) respond(system, user string) (string, *FakeRule, time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, rule := range p.script.Rules {
+		if !rule.matches(system, user) {
+			continue
+		}
+		rule.hits++
+		latency := p.script.Latency
+		if rule.Latency > 0 {
+			latency = rule.Latency
+		}
+		if rule.Error != "" {
+			return "", rule, latency, &ProviderError{
+				Provider:   p.Name(),
+				Kind:       rule.ErrorKind,
+				RetryAfter: rule.RetryAfter,
+				Err:        errors.New(rule.Error),
+			}
+		}
+		return rule.Responses[(rule.hits-1)%len(rule.Responses)], rule, latency, nil
+	}
+
+	if p.queue < len(p.script.Queue) {
+		p.queue++
+		return p.script.Queue[p.queue-1], nil, p.script.Latency, nil
+	}
+	if p.script.Default != "" {
+		return p.script.Default, nil, p.script.Latency, nil
+	}
+	return "", nil, p.script.Latency, fmt.Errorf("fake 脚本中没有匹配的应答")
+}
+
+// Generate 实现BaseChatModel接口
+func (p *FakeProvider) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	started := time.Now()
+	var system, user []string
+	for _, msg := range input {
+		if msg.Role == schema.System {
+			system = append(system, msg.Content)
+		} else {
+			user = append(user, msg.Content)
+		}
+	}
+	call := FakeCall{System: strings.Join(system, "\n\n"), User: strings.Join(user, "\n\n")}
+
+	output, rule, latency, err := p.respond(call.System, call.User)
+	if rule != nil {
+		call.Rule = rule.Name
+	}
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	call.Output = output
+	call.Err = err
+	p.mu.Lock()
+	p.calls = append(p.calls, call)
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	inputTokens := EstimateMessagesTokens(input)
+	outputTokens := EstimateTokens(output)
+	if rule != nil && rule.InputTokens > 0 {
+		inputTokens = rule.InputTokens
+	}
+	if rule != nil && rule.OutputTokens > 0 {
+		outputTokens = rule.OutputTokens
+	}
+	recordUsage(ctx, p, started, UsageRecord{
+		Model:        p.cfg.Model,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		Estimated:    rule == nil || rule.InputTokens == 0 || rule.OutputTokens == 0,
+	})
+
+	return schema.AssistantMessage(output, nil), nil
+}
+
+// Stream 实现BaseChatModel接口，把应答按行拆成多个分片返回
+func (p *FakeProvider) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := p.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return lineStream(msg.Content), nil
+}
+
+// lineStream 把完整的应答按行拆成流，供没有真实流式接口的提供商使用
+func lineStream(content string) *schema.StreamReader[*schema.Message] {
+	lines := strings.SplitAfter(content, "\n")
+	chunks := make([]*schema.Message, 0, len(lines))
+	for _, line := range lines {
+		chunks = append(chunks, schema.AssistantMessage(line, nil))
+	}
+	return schema.StreamReaderFromArray(chunks)
+}
+
+// DefaultFakeScript 内置的演示脚本：门房确认需求，编排器依次执行 insight、xhs_post 后完成任务
+func DefaultFakeScript() *FakeScript {
+	return &FakeScript{
+		Latency: 300 * time.Millisecond,
+		Rules: []*FakeRule{
+			{
+				Name:      "concierge",
+				System:    `Concierge`,
+				Responses: []string{"收到！我理解你想围绕这个话题做一篇小红书内容，面向同样有困扰的年轻人，风格真诚不说教。如果没问题，回复「确认」我就开始安排。"},
+			},
+			{
+				Name:   "orchestrator",
+				System: `Orchestrator（编排员）`,
+				Responses: []string{
+					`<tactics>先挖掘目标受众的情绪和动机，再据此写小红书帖子。</tactics>
+<execute_step action="insight" instruction="分析目标受众在这个话题下的情绪和动机"/>`,
+					`<tactics>洞察已经完成，按洞察写小红书帖子。</tactics>
+<execute_step action="xhs_post" instruction="基于 @insight1 写一篇小红书帖子"/>`,
+					`<tactics>帖子已经完成，任务结束。</tactics>
+<task_completed/>`,
+				},
+			},
+			{
+				Name:   "insight",
+				System: `一步一步收束推导出好的洞察`,
+				Responses: []string{`<insight1>
+表层需求是想要一份实用的方法，深层是对自己落后于同龄人的焦虑。受众既想被理解，又不想被说教，真实的个人经历比道理更有说服力。
+</insight1>`},
+			},
+			{
+				Name:   "xhs_post",
+				System: `资深的小红书博主`,
+				Responses: []string{`<xhs_post1>
+<title>试了一个月，我终于不焦虑了</title>
+<body>以前总觉得别人都在往前跑，只有我原地踏步。
+
+后来我把目标拆成每天一件小事，做完就打个勾。一个月下来，清单满了，心也稳了。
+
+你们有没有类似的经历？评论区聊聊</body>
+<hashtags>#自我成长 #情绪管理 #生活记录</hashtags>
+</xhs_post1>`},
+			},
+			{
+				Name:   "websearch",
+				System: `严谨的信息研究员`,
+				Responses: []string{`<websearch1>
+<summary>这是离线演示模式下的示例搜索要点。</summary>
+<sources>1</sources>
+</websearch1>`},
+			},
+		},
+		Default: "（离线演示模式）这是 fake 模型的默认应答。",
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"loomi2.0/config"
+)
+
+func TestFakeProvider(t *testing.T) {
+	script := &FakeScript{
+		Rules: []*FakeRule{
+			{Name: "flaky", System: "编排", Error: "模拟超时", Times: 1},
+			{Name: "orchestrator", System: "编排", Responses: []string{"第一轮", "第二轮"}},
+		},
+		Queue:   []string{"队列应答"},
+		Default: "默认应答",
+	}
+	provider, err := NewFakeProvider(config.ProviderConfig{Name: "fake", Type: config.ProviderTypeFake}, script)
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := provider.CallLLM(ctx, "你是编排员", "任务", nil); err == nil {
+		t.Error("第一次调用应该返回注入的错误")
+	}
+	for _, want := range []string{"第一轮", "第二轮", "第一轮"} {
+		if got, err := provider.CallLLM(ctx, "你是编排员", "任务", nil); err != nil || got != want {
+			t.Errorf("规则应答错误，期望 %q，实际 %q (%v)", want, got, err)
+		}
+	}
+	for _, want := range []string{"队列应答", "默认应答"} {
+		if got, _ := provider.CallLLM(ctx, "其他", "任务", nil); got != want {
+			t.Errorf("期望 %q，实际 %q", want, got)
+		}
+	}
+	if calls := provider.Calls(); len(calls) != 6 || calls[0].Rule != "flaky" {
+		t.Errorf("调用记录错误: %+v", calls)
+	}
+}
@@ -0,0 +1,283 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"loomi2.0/config"
+)
+
+// ErrCircuitOpen 提供商处于熔断状态，本次调用被跳过
+var ErrCircuitOpen = errors.New("熔断中")
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常调用
+	CircuitOpen     CircuitState = "open"      // 连续失败后暂停调用，冷却结束前直接跳过
+	CircuitHalfOpen CircuitState = "half_open" // 冷却结束，放行一次试探调用
+)
+
+// circuitStateNames 熔断器状态的显示名称
+var circuitStateNames = map[CircuitState]string{
+	CircuitClosed:   "正常",
+	CircuitOpen:     "熔断中",
+	CircuitHalfOpen: "试探中",
+}
+
+// CircuitBreaker 单个提供商的熔断器：连续失败达到阈值后打开，冷却后放行一次试探，试探成功则恢复
+type CircuitBreaker struct {
+	provider  string
+	threshold int
+	cooldown  time.Duration
+	state     CircuitState
+	failures  int       // 连续失败次数
+	openedAt  time.Time // 最近一次打开的时间
+	lastErr   error     // 最近一次失败的错误
+	probing   bool      // 半开状态下是否已放行试探调用
+	mu        sync.Mutex
+}
+
+// NewCircuitBreaker 按配置创建熔断器
+func NewCircuitBreaker(provider string, cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		provider:  provider,
+		threshold: cfg.FailureThreshold,
+		cooldown:  cfg.Cooldown,
+		state:     CircuitClosed,
+	}
+}
+
+// Allow 是否可以调用；打开状态冷却结束后转为半开，只放行一次试探
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Record 记录一次调用的结果：提供商不可用时计为失败，提供商正常应答（包括内容被拦截等）时恢复，
+// 调用方取消等与提供商无关的错误不计入
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var providerErr *ProviderError
+	switch {
+	case err == nil || (errors.As(err, &providerErr) && !providerUnavailable(err)):
+		b.state = CircuitClosed
+		b.failures = 0
+		b.lastErr = nil
+	case providerUnavailable(err):
+		b.failures++
+		b.lastErr = err
+		if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+			b.state = CircuitOpen
+			b.openedAt = time.Now()
+		}
+	}
+	b.probing = false
+}
+
+// Status 获取熔断器状态
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := CircuitStatus{Provider: b.provider, State: b.state, Failures: b.failures, LastError: b.lastErr}
+	if b.state == CircuitOpen {
+		if remaining := b.cooldown - time.Since(b.openedAt); remaining > 0 {
+			status.RetryIn = remaining
+		}
+	}
+	return status
+}
+
+// openError 熔断时跳过调用返回的错误，类别沿用最近一次失败
+func (b *CircuitBreaker) openError() error {
+	status := b.Status()
+	return &ProviderError{
+		Provider:   b.provider,
+		Kind:       ErrorKindOf(status.LastError),
+		RetryAfter: status.RetryIn,
+		Err:        fmt.Errorf("%w，%s 后恢复试探", ErrCircuitOpen, status.RetryIn.Round(time.Second)),
+	}
+}
+
+// CircuitStatus 熔断器状态
+type CircuitStatus struct {
+	Provider  string
+	State     CircuitState
+	Failures  int           // 连续失败次数
+	RetryIn   time.Duration // 熔断中时距离放行试探的时间
+	LastError error
+}
+
+// String 形如 "deepseek 熔断中（连续失败 3 次，42s 后试探）"
+func (s CircuitStatus) String() string {
+	if s.State == CircuitOpen {
+		return fmt.Sprintf("%s %s（连续失败 %d 次，%s 后试探）", s.Provider, circuitStateNames[s.State], s.Failures, s.RetryIn.Round(time.Second))
+	}
+	if s.Failures > 0 {
+		return fmt.Sprintf("%s %s（连续失败 %d 次）", s.Provider, circuitStateNames[s.State], s.Failures)
+	}
+	return fmt.Sprintf("%s %s", s.Provider, circuitStateNames[s.State])
+}
+
+// providerUnavailable 错误是否说明提供商暂时不可用：限流、服务端错误、超时或鉴权失败，换用其他提供商可能成功
+func providerUnavailable(err error) bool {
+	kind := ErrorKindOf(err)
+	return kind.Retryable() || kind == ErrorKindAuth
+}
+
+// canFallback 失败后是否换用后备提供商；流式输出已经开始后中断的调用不换，以免重复输出
+func canFallback(err error) bool {
+	var interrupted *streamInterruptedError
+	return providerUnavailable(err) && !errors.As(err, &interrupted)
+}
+
+// CallResult 一次模型调用的结果
+type CallResult struct {
+	Output       string
+	Provider     string // 实际应答的提供商
+	FallbackFrom string // 由后备提供商应答时为原本调用的提供商，否则为空
+}
+
+// FallbackHandler 换用后备提供商时的回调
+type FallbackHandler func(from, to string, err error)
+
+type fallbackFromKey struct{}
+
+// withFallbackFrom 返回标记了原本调用的提供商的 ctx，后备提供商的用量记录会带上该标记
+func withFallbackFrom(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, fallbackFromKey{}, provider)
+}
+
+// fallbackFromContext 获取 ctx 中原本调用的提供商，不是后备调用时为空
+func fallbackFromContext(ctx context.Context) string {
+	provider, _ := ctx.Value(fallbackFromKey{}).(string)
+	return provider
+}
+
+// SetFallbacks 设置提供商不可用时依次换用的提供商
+func (m *ModelManager) SetFallbacks(provider string, fallbacks []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallbacks[provider] = append([]string(nil), fallbacks...)
+}
+
+// SetCircuitBreaker 设置熔断器配置，所有提供商的熔断状态重新统计
+func (m *ModelManager) SetCircuitBreaker(cfg config.CircuitBreakerConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.circuit = cfg
+	m.breakers = make(map[string]*CircuitBreaker)
+}
+
+// SetFallbackHandler 设置换用后备提供商时的回调
+func (m *ModelManager) SetFallbackHandler(handler FallbackHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onFallback = handler
+}
+
+// breaker 获取提供商的熔断器，没有时创建
+func (m *ModelManager) breaker(provider string) *CircuitBreaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.breakers[provider] == nil {
+		m.breakers[provider] = NewCircuitBreaker(provider, m.circuit)
+	}
+	return m.breakers[provider]
+}
+
+// fallbackChain 调用 provider 时依次尝试的提供商：provider 本身和已注册的后备提供商
+func (m *ModelManager) fallbackChain(provider ModelProvider) []ModelProvider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chain := []ModelProvider{provider}
+	for _, name := range m.fallbacks[provider.Name()] {
+		if fallback, ok := m.providers[name]; ok && fallback != provider {
+			chain = append(chain, fallback)
+		}
+	}
+	return chain
+}
+
+// CircuitStatuses 已调用过的提供商的熔断状态，按名称排列
+func (m *ModelManager) CircuitStatuses() []CircuitStatus {
+	m.mu.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(m.breakers))
+	for _, breaker := range m.breakers {
+		breakers = append(breakers, breaker)
+	}
+	m.mu.RUnlock()
+
+	statuses := make([]CircuitStatus, 0, len(breakers))
+	for _, breaker := range breakers {
+		statuses = append(statuses, breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Provider < statuses[j].Provider
+	})
+	return statuses
+}
+
+// callWithFallback 依次调用后备链上的提供商，跳过熔断中的提供商，直到有提供商应答或遇到不能换用的错误
+func (m *ModelManager) callWithFallback(ctx context.Context, provider ModelProvider, call func(ctx context.Context, provider ModelProvider) (string, error)) (CallResult, error) {
+	m.mu.RLock()
+	onFallback := m.onFallback
+	m.mu.RUnlock()
+
+	var lastErr error
+	for _, candidate := range m.fallbackChain(provider) {
+		breaker := m.breaker(candidate.Name())
+		if !breaker.Allow() {
+			lastErr = breaker.openError()
+			continue
+		}
+
+		callCtx := ctx
+		result := CallResult{Provider: candidate.Name()}
+		if candidate != provider {
+			result.FallbackFrom = provider.Name()
+			callCtx = withFallbackFrom(ctx, provider.Name())
+			if onFallback != nil {
+				onFallback(provider.Name(), candidate.Name(), lastErr)
+			}
+		}
+
+		output, err := call(callCtx, candidate)
+		breaker.Record(err)
+		if err == nil {
+			result.Output = output
+			return result, nil
+		}
+		lastErr = err
+		if !canFallback(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	return CallResult{}, lastErr
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"loomi2.0/config"
+)
+
+func TestFallbackChain(t *testing.T) {
+	t.Setenv("LOOMI_DEEPSEEK_API_KEY", "test-key")
+	if err := InitModelManager(); err != nil {
+		t.Fatalf("初始化模型管理器失败: %v", err)
+	}
+	manager := GetModelManager()
+
+	down, _ := NewFakeProvider(config.ProviderConfig{Name: "fake-down", Type: config.ProviderTypeFake}, &FakeScript{
+		Rules: []*FakeRule{{Name: "down", Error: "服务不可用", ErrorKind: ErrorKindServer}},
+	})
+	up, _ := NewFakeProvider(config.ProviderConfig{Name: "fake-up", Type: config.ProviderTypeFake}, &FakeScript{Default: "后备应答"})
+	manager.RegisterProvider(down)
+	manager.RegisterProvider(up)
+	manager.SetFallbacks("fake-down", []string{"fake-up"})
+
+	previous := GetCurrentModelName()
+	retry := manager.RetryPolicy()
+	manager.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	manager.SetCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	defer func() {
+		manager.SetRetryPolicy(retry)
+		manager.SetCircuitBreaker(config.GetConfig().Circuit)
+		if previous != "" {
+			manager.SetCurrentProvider(previous)
+		}
+	}()
+	if err := manager.SetCurrentProvider("fake-down"); err != nil {
+		t.Fatalf("设置当前模型失败: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		result, err := manager.Call(ctx, "系统", "任务", nil)
+		if err != nil || result.Output != "后备应答" || result.Provider != "fake-up" || result.FallbackFrom != "fake-down" {
+			t.Fatalf("第 %d 次调用应当由后备提供商应答: %+v (%v)", i+1, result, err)
+		}
+	}
+	// 第一次失败后熔断，第二次直接跳过
+	if calls := len(down.Calls()); calls != 1 {
+		t.Errorf("熔断后不应再调用不可用的提供商，实际调用 %d 次", calls)
+	}
+	records := GetUsageRecords()
+	if last := records[len(records)-1]; last.Provider != "fake-up" || last.FallbackFrom != "fake-down" {
+		t.Errorf("用量记录应当标明后备应答: %+v", last)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker("fake", config.CircuitBreakerConfig{FailureThreshold: 2, Cooldown: 20 * time.Millisecond})
+	unavailable := &ProviderError{Provider: "fake", Kind: ErrorKindServer, Err: errors.New("服务不可用")}
+
+	// 连续失败达到阈值后打开
+	breaker.Record(unavailable)
+	if status := breaker.Status(); status.State != CircuitClosed || status.Failures != 1 || !breaker.Allow() {
+		t.Fatalf("未达到阈值时应当保持正常: %v", status)
+	}
+	breaker.Record(unavailable)
+	if status := breaker.Status(); status.State != CircuitOpen || status.RetryIn <= 0 || breaker.Allow() {
+		t.Fatalf("达到阈值后应当熔断: %v", status)
+	}
+	if err := breaker.openError(); !errors.Is(err, ErrCircuitOpen) || ErrorKindOf(err) != ErrorKindServer {
+		t.Errorf("熔断时的错误应当沿用最近一次失败的类别: %v", err)
+	}
+
+	// 冷却结束后转为半开，只放行一次试探；试探失败重新打开
+	time.Sleep(30 * time.Millisecond)
+	if !breaker.Allow() || breaker.Status().State != CircuitHalfOpen {
+		t.Fatalf("冷却结束后应当放行试探: %v", breaker.Status())
+	}
+	if breaker.Allow() {
+		t.Error("半开状态只放行一次试探")
+	}
+	breaker.Record(unavailable)
+	if status := breaker.Status(); status.State != CircuitOpen || breaker.Allow() {
+		t.Fatalf("试探失败后应当重新熔断: %v", status)
+	}
+
+	// 试探成功后恢复正常
+	time.Sleep(30 * time.Millisecond)
+	if !breaker.Allow() {
+		t.Fatal("冷却结束后应当放行试探")
+	}
+	breaker.Record(nil)
+	if status := breaker.Status(); status.State != CircuitClosed || status.Failures != 0 || !breaker.Allow() || !breaker.Allow() {
+		t.Errorf("试探成功后应当恢复正常: %v", status)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerErrors(t *testing.T) {
+	breaker := NewCircuitBreaker("fake", config.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+
+	// 调用方取消不计入失败，提供商正常应答的错误（如内容被拦截）视为恢复
+	breaker.Record(context.Canceled)
+	breaker.Record(&ProviderError{Provider: "fake", Kind: ErrorKindContentFiltered, Err: errors.New("内容被拦截")})
+	if status := breaker.Status(); status.State != CircuitClosed || status.Failures != 0 {
+		t.Errorf("与提供商可用性无关的错误不应熔断: %v", status)
+	}
+}
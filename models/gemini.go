@@ -2,14 +2,20 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"loomi2.0/config"
 )
 
 // GeminiProvider Gemini模型提供商
@@ -17,38 +23,30 @@ type GeminiProvider struct {
 	*BaseProvider
 	client *genai.Client
 	model  *genai.GenerativeModel
+	cfg    config.ProviderConfig
 	config map[string]interface{}
 }
 
-// GeminiConfig Gemini配置
-const (
-	GeminiAPIKey     = "your-gemini-api-key" // 需要替换为实际的API密钥
-	GeminiModelName  = "gemini-1.5-pro"
-	
-	// 费用计算常量
-	GeminiCostInputPer1M  = 0.375
-	GeminiCostOutputPer1M = 1.875
-)
-
 // NewGeminiProvider 创建Gemini提供商
-func NewGeminiProvider() (*GeminiProvider, error) {
+func NewGeminiProvider(cfg config.ProviderConfig) (*GeminiProvider, error) {
 	// 创建Gemini客户端
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(GeminiAPIKey))
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
 	if err != nil {
 		return nil, fmt.Errorf("创建Gemini客户端失败: %v", err)
 	}
 
 	// 创建模型
-	model := client.GenerativeModel(GeminiModelName)
+	model := client.GenerativeModel(cfg.Model)
 
 	// 创建基础提供商
-	baseProvider := NewBaseProvider("gemini-1.5-pro", "Gemini 1.5 Pro", nil)
+	baseProvider := NewBaseProvider(cfg.Name, cfg.DisplayName, nil)
 
 	provider := &GeminiProvider{
 		BaseProvider: baseProvider,
 		client:      client,
 		model:       model,
+		cfg:         cfg,
 		config:      make(map[string]interface{}),
 	}
 
@@ -60,13 +58,15 @@ func NewGeminiProvider() (*GeminiProvider, error) {
 
 // CalculateCost 计算费用
 func (p *GeminiProvider) CalculateCost(inputTokens, outputTokens, thinkingTokens int) float64 {
-	inputCost := float64(inputTokens) / 1_000_000 * GeminiCostInputPer1M
-	outputCost := float64(outputTokens+thinkingTokens) / 1_000_000 * GeminiCostOutputPer1M
+	inputCost := float64(inputTokens) / 1_000_000 * p.cfg.Pricing.InputPer1M
+	outputCost := float64(outputTokens+thinkingTokens) / 1_000_000 * p.cfg.Pricing.OutputPer1M
 	return inputCost + outputCost
 }
 
 // Generate 实现BaseChatModel接口
 func (p *GeminiProvider) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	started := time.Now()
+
 	// 转换消息格式
 	parts := make([]genai.Part, 0, len(input))
 	for _, msg := range input {
@@ -76,12 +76,12 @@ func (p *GeminiProvider) Generate(ctx context.Context, input []*schema.Message,
 	// 调用Gemini API
 	resp, err := p.model.GenerateContent(ctx, parts...)
 	if err != nil {
-		return nil, fmt.Errorf("Gemini API调用失败: %v", err)
+		return nil, p.providerError(err)
 	}
 
 	// 处理响应
 	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("Gemini API返回空响应")
+		return nil, &ProviderError{Provider: p.Name(), Kind: ErrorKindServer, Err: errors.New("API返回空响应")}
 	}
 
 	content := ""
@@ -94,18 +94,17 @@ func (p *GeminiProvider) Generate(ctx context.Context, input []*schema.Message,
 	// 处理文本
 	content = p.ProcessText(content)
 
-	// 更新统计信息（Gemini可能没有详细的token信息）
-	inputTokens := len(input[0].Content) / 4  // 估算
-	outputTokens := len(content) / 4       // 估算
-	thinkingTokens := 0
-
-	cost := p.CalculateCost(inputTokens, outputTokens, thinkingTokens)
-	
-	// 更新全局统计
-	modelManager := GetModelManager()
-	if modelManager != nil {
-		modelManager.UpdateStats(inputTokens, outputTokens, thinkingTokens, cost)
+	// 更新统计信息，优先使用接口返回的用量，没有时本地估算
+	usage := UsageRecord{
+		InputTokens:  EstimateMessagesTokens(input),
+		OutputTokens: EstimateTokens(content),
+		Estimated:    true,
+	}
+	if resp.UsageMetadata != nil {
+		usage = geminiUsage(resp.UsageMetadata)
 	}
+	usage.Model = p.cfg.Model
+	recordUsage(ctx, p, started, usage)
 
 	return &schema.Message{
 		Role:    "assistant",
@@ -129,16 +128,25 @@ func (p *GeminiProvider) Stream(ctx context.Context, input []*schema.Message, op
 	
 	// 创建一个简单的流适配器
 	reader, writer := schema.Pipe[*schema.Message](5)
-	
+	usage := newStreamUsage(ctx, p, p.cfg.Model, input)
+
 	go func() {
 		defer writer.Close()
-		
+
 		for {
 			msg, err := streamReader.Recv()
 			if err != nil {
+				if streamReader.usage != nil {
+					usage.Report(geminiUsage(streamReader.usage))
+				}
+				usage.Finish(err)
+				if err != io.EOF {
+					err = p.providerError(err)
+				}
 				writer.Send(msg, err)
 				break
 			}
+			usage.Add(msg.Content)
 			writer.Send(msg, nil)
 		}
 	}()
@@ -146,6 +154,54 @@ func (p *GeminiProvider) Stream(ctx context.Context, input []*schema.Message, op
 	return reader, nil
 }
 
+// providerError 把 genai 的错误转换为 ProviderError
+func (p *GeminiProvider) providerError(err error) error {
+	providerErr := &ProviderError{Provider: p.Name(), Kind: ErrorKindUnknown, Err: err}
+	var blockedErr *genai.BlockedError
+	var apiErr *googleapi.Error
+	switch {
+	case errors.As(err, &blockedErr):
+		providerErr.Kind = ErrorKindContentFiltered
+	case errors.As(err, &apiErr):
+		providerErr.StatusCode = apiErr.Code
+		providerErr.Kind = classifyError(apiErr.Code, "", apiErr.Message)
+		providerErr.RetryAfter = geminiRetryDelay(apiErr)
+	default:
+		if kind, ok := classifyTransportError(err); ok {
+			providerErr.Kind = kind
+		}
+	}
+	return providerErr
+}
+
+// geminiRetryDelay 服务端要求的重试等待时间：优先 Retry-After 响应头，其次错误详情中的 RetryInfo
+func geminiRetryDelay(apiErr *googleapi.Error) time.Duration {
+	if delay := parseRetryAfter(apiErr.Header.Get("Retry-After")); delay > 0 {
+		return delay
+	}
+	for _, detail := range apiErr.Details {
+		info, ok := detail.(map[string]interface{})
+		if !ok || !strings.HasSuffix(fmt.Sprint(info["@type"]), "google.rpc.RetryInfo") {
+			continue
+		}
+		if value, ok := info["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(value); err == nil {
+				return delay
+			}
+		}
+	}
+	return 0
+}
+
+// geminiUsage 转换接口返回的用量
+func geminiUsage(meta *genai.UsageMetadata) UsageRecord {
+	return UsageRecord{
+		InputTokens:  int(meta.PromptTokenCount),
+		OutputTokens: int(meta.CandidatesTokenCount),
+		CachedTokens: int(meta.CachedContentTokenCount),
+	}
+}
+
 // GetInputType 获取输入类型
 func (p *GeminiProvider) GetInputType() string {
 	return "message"
@@ -185,12 +241,9 @@ func (p *GeminiProvider) GetCallbacks() callbacks.Handler {
 	return nil
 }
 
-// ProcessText 处理文本
+// ProcessText 处理完整的应答文本：只去掉首尾空白，不改动内容，编排器依赖引号解析标签属性
 func (p *GeminiProvider) ProcessText(text string) string {
-	// 去掉中文双引号
-	text = strings.ReplaceAll(text, "\"", "")
-	text = strings.ReplaceAll(text, "\"", "")
-	return text
+	return strings.TrimSpace(text)
 }
 
 // CallLLM 调用LLM（兼容原有接口）
@@ -207,7 +260,7 @@ func (p *GeminiProvider) CallLLM(ctx context.Context, systemPrompt, userPrompt s
 	// 调用模型
 	response, err := p.Generate(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("调用模型失败: %v", err)
+		return "", fmt.Errorf("调用模型失败: %w", err)
 	}
 
 	return response.Content, nil
@@ -215,21 +268,28 @@ func (p *GeminiProvider) CallLLM(ctx context.Context, systemPrompt, userPrompt s
 
 // GeminiStreamReader Gemini流读取器
 type GeminiStreamReader struct {
-	iter *genai.GenerateContentResponseIterator
+	iter  *genai.GenerateContentResponseIterator
+	usage *genai.UsageMetadata // 最近一个分片返回的用量，最后一个分片为整次调用的用量
 }
 
 func (r *GeminiStreamReader) Recv() (*schema.Message, error) {
 	resp, err := r.iter.Next()
+	if err == iterator.Done {
+		return nil, io.EOF
+	}
 	if err != nil {
 		return nil, err
 	}
+	if resp.UsageMetadata != nil {
+		r.usage = resp.UsageMetadata
+	}
 
 	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("空响应")
+		return &schema.Message{Role: "assistant"}, nil
 	}
 
 	content := ""
-	if len(resp.Candidates[0].Content.Parts) > 0 {
+	if resp.Candidates[0].Content != nil && len(resp.Candidates[0].Content.Parts) > 0 {
 		if text, ok := resp.Candidates[0].Content.Parts[0].(genai.Text); ok {
 			content = string(text)
 		}
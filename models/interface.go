@@ -45,6 +45,42 @@ func GetSessionStats() SessionStats {
 	return manager.GetStats()
 }
 
+// GetUsageSummary 按维度汇总会话中的模型调用
+func GetUsageSummary(dim UsageDimension) []UsageSummary {
+	manager := GetModelManager()
+	if manager == nil {
+		return nil
+	}
+	return manager.Ledger().Summarize(dim)
+}
+
+// GetUsageRecords 获取用量账本中的所有调用记录
+func GetUsageRecords() []UsageRecord {
+	manager := GetModelManager()
+	if manager == nil {
+		return nil
+	}
+	return manager.Ledger().Records()
+}
+
+// GetBudgetStatuses 获取设置了上限的会话预算和 ctx 中任务预算的使用情况
+func GetBudgetStatuses(ctx context.Context) []BudgetStatus {
+	manager := GetModelManager()
+	if manager == nil {
+		return nil
+	}
+	return manager.BudgetStatuses(ctx)
+}
+
+// GetCircuitStatuses 获取已调用过的提供商的熔断状态
+func GetCircuitStatuses() []CircuitStatus {
+	manager := GetModelManager()
+	if manager == nil {
+		return nil
+	}
+	return manager.CircuitStatuses()
+}
+
 // CallLLM 调用LLM（全局函数）
 func CallLLM(ctx context.Context, systemPrompt, userPrompt string, options map[string]interface{}) (string, error) {
 	manager := GetModelManager()
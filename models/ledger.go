@@ -0,0 +1,200 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageTags 模型调用的归属，随 ctx 传递到提供商并记入用量账本
+type UsageTags struct {
+	Session string // 会话ID
+	Agent   string // 发起调用的智能体，例如 concierge、orchestrator
+	Action  string // 智能体内的行动，例如 chat、plan、hitpoint
+}
+
+type usageTagsKey struct{}
+
+// WithUsageTags 返回携带归属标签的 ctx，tags 中为空的字段沿用 ctx 中已有的标签
+func WithUsageTags(ctx context.Context, tags UsageTags) context.Context {
+	current := UsageTagsFromContext(ctx)
+	if tags.Session == "" {
+		tags.Session = current.Session
+	}
+	if tags.Agent == "" {
+		tags.Agent = current.Agent
+	}
+	if tags.Action == "" {
+		tags.Action = current.Action
+	}
+	return context.WithValue(ctx, usageTagsKey{}, tags)
+}
+
+// UsageTagsFromContext 获取 ctx 中的归属标签
+func UsageTagsFromContext(ctx context.Context) UsageTags {
+	tags, _ := ctx.Value(usageTagsKey{}).(UsageTags)
+	return tags
+}
+
+// UsageRecord 用量账本中的一次模型调用
+type UsageRecord struct {
+	Time           time.Time     `json:"time"` // 调用开始时间
+	Session        string        `json:"session,omitempty"`
+	Agent          string        `json:"agent,omitempty"`
+	Action         string        `json:"action,omitempty"`
+	Provider       string        `json:"provider"`
+	FallbackFrom   string        `json:"fallback_from,omitempty"` // 由后备提供商应答时为原本调用的提供商
+	Model          string        `json:"model,omitempty"`
+	InputTokens    int           `json:"input_tokens"`
+	OutputTokens   int           `json:"output_tokens"`
+	ThinkingTokens int           `json:"thinking_tokens"`
+	CachedTokens   int           `json:"cached_tokens"` // 输入中命中缓存的部分，已包含在 InputTokens 中
+	Latency        time.Duration `json:"latency"`
+	Cost           float64       `json:"cost"`
+	Stream         bool          `json:"stream,omitempty"`
+	Estimated      bool          `json:"estimated,omitempty"` // 接口没有返回用量，token 为本地估算
+}
+
+// UsageDimension 汇总用量的维度
+type UsageDimension string
+
+const (
+	UsageBySession  UsageDimension = "session"
+	UsageByAgent    UsageDimension = "agent"
+	UsageByAction   UsageDimension = "action"
+	UsageByProvider UsageDimension = "provider"
+	UsageByModel    UsageDimension = "model"
+)
+
+// UsageDimensions 所有汇总维度
+var UsageDimensions = []UsageDimension{UsageByProvider, UsageByModel, UsageByAgent, UsageByAction, UsageBySession}
+
+// key 记录在某个维度上的取值，没有标签时返回 "-"
+func (r UsageRecord) key(dim UsageDimension) string {
+	var key string
+	switch dim {
+	case UsageBySession:
+		key = r.Session
+	case UsageByAgent:
+		key = r.Agent
+	case UsageByAction:
+		key = r.Action
+		if r.Agent != "" && r.Action != "" {
+			key = r.Agent + "/" + r.Action
+		}
+	case UsageByProvider:
+		key = r.Provider
+	case UsageByModel:
+		key = r.Model
+	}
+	if key == "" {
+		return "-"
+	}
+	return key
+}
+
+// UsageSummary 一组模型调用的用量汇总
+type UsageSummary struct {
+	Key            string        `json:"key,omitempty"`
+	Calls          int           `json:"calls"`
+	InputTokens    int           `json:"input_tokens"`
+	OutputTokens   int           `json:"output_tokens"`
+	ThinkingTokens int           `json:"thinking_tokens"`
+	CachedTokens   int           `json:"cached_tokens"`
+	Latency        time.Duration `json:"latency"` // 累计耗时
+	Cost           float64       `json:"cost"`
+}
+
+// add 计入一次调用
+func (s *UsageSummary) add(r UsageRecord) {
+	s.Calls++
+	s.InputTokens += r.InputTokens
+	s.OutputTokens += r.OutputTokens
+	s.ThinkingTokens += r.ThinkingTokens
+	s.CachedTokens += r.CachedTokens
+	s.Latency += r.Latency
+	s.Cost += r.Cost
+}
+
+// AverageLatency 平均每次调用的耗时
+func (s UsageSummary) AverageLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Calls)
+}
+
+// UsageLedger 用量账本，按时间顺序记录每次模型调用
+type UsageLedger struct {
+	records []UsageRecord
+	mu      sync.RWMutex
+}
+
+// NewUsageLedger 创建用量账本
+func NewUsageLedger() *UsageLedger {
+	return &UsageLedger{}
+}
+
+// Append 追加一次调用
+func (l *UsageLedger) Append(record UsageRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+// Records 返回所有调用记录的副本
+func (l *UsageLedger) Records() []UsageRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]UsageRecord(nil), l.records...)
+}
+
+// Total 汇总所有调用
+func (l *UsageLedger) Total() UsageSummary {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var total UsageSummary
+	for _, record := range l.records {
+		total.add(record)
+	}
+	return total
+}
+
+// Summarize 按维度汇总，结果按费用从高到低排列，费用相同时按调用次数
+func (l *UsageLedger) Summarize(dim UsageDimension) []UsageSummary {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	groups := make(map[string]*UsageSummary)
+	for _, record := range l.records {
+		key := record.key(dim)
+		if groups[key] == nil {
+			groups[key] = &UsageSummary{Key: key}
+		}
+		groups[key].add(record)
+	}
+
+	summaries := make([]UsageSummary, 0, len(groups))
+	for _, summary := range groups {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Cost != summaries[j].Cost {
+			return summaries[i].Cost > summaries[j].Cost
+		}
+		if summaries[i].Calls != summaries[j].Calls {
+			return summaries[i].Calls > summaries[j].Calls
+		}
+		return summaries[i].Key < summaries[j].Key
+	})
+	return summaries
+}
+
+// Reset 清空账本
+func (l *UsageLedger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsageLedger(t *testing.T) {
+	ledger := NewUsageLedger()
+	ledger.Append(UsageRecord{Agent: "concierge", Action: "chat", Provider: "deepseek", InputTokens: 100, Cost: 0.01})
+	ledger.Append(UsageRecord{Agent: "orchestrator", Action: "hitpoint", Provider: "deepseek", InputTokens: 300, Cost: 0.03})
+	ledger.Append(UsageRecord{Agent: "orchestrator", Action: "hitpoint", Provider: "gemini", InputTokens: 200, Cost: 0.02})
+
+	byAction := ledger.Summarize(UsageByAction)
+	if len(byAction) != 2 || byAction[0].Key != "orchestrator/hitpoint" || byAction[0].Calls != 2 || byAction[0].InputTokens != 500 {
+		t.Errorf("按行动汇总错误: %+v", byAction)
+	}
+	if byProvider := ledger.Summarize(UsageByProvider); len(byProvider) != 2 || byProvider[0].Key != "deepseek" {
+		t.Errorf("按提供商汇总错误: %+v", byProvider)
+	}
+	if total := ledger.Total(); total.Calls != 3 || total.InputTokens != 600 {
+		t.Errorf("总计错误: %+v", total)
+	}
+
+	ledger.Reset()
+	if total := ledger.Total(); total.Calls != 0 || len(ledger.Records()) != 0 {
+		t.Errorf("清空后账本应当为空: %+v", total)
+	}
+}
+
+func TestWithUsageTags(t *testing.T) {
+	ctx := WithUsageTags(context.Background(), UsageTags{Session: "s1", Agent: "orchestrator"})
+	ctx = WithUsageTags(ctx, UsageTags{Action: "plan"})
+	if tags := UsageTagsFromContext(ctx); tags != (UsageTags{Session: "s1", Agent: "orchestrator", Action: "plan"}) {
+		t.Errorf("空字段应当沿用已有标签: %+v", tags)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/cloudwego/eino/schema"
+	"loomi2.0/config"
 )
 
 // SessionStats 会话统计
@@ -22,6 +23,14 @@ type ModelManager struct {
 	providers      map[string]ModelProvider
 	currentProvider ModelProvider
 	stats          SessionStats
+	ledger         *UsageLedger
+	budget         config.BudgetConfig
+	sessionBudget  *Budget
+	retry          RetryPolicy
+	fallbacks      map[string][]string // 提供商名称 -> 不可用时依次换用的提供商
+	circuit        config.CircuitBreakerConfig
+	breakers       map[string]*CircuitBreaker
+	onFallback     FallbackHandler
 	mu             sync.RWMutex
 }
 
@@ -36,6 +45,8 @@ func InitModelManager() error {
 	once.Do(func() {
 		manager = &ModelManager{
 			providers: make(map[string]ModelProvider),
+			ledger:    NewUsageLedger(),
+			fallbacks: make(map[string][]string),
 		}
 		err = manager.init()
 	})
@@ -48,6 +59,10 @@ func GetModelManager() *ModelManager {
 }
 
 func (m *ModelManager) init() error {
+	m.SetBudget(config.GetConfig().Budget)
+	m.SetRetryPolicy(NewRetryPolicy(config.GetConfig().Retry))
+	m.SetCircuitBreaker(config.GetConfig().Circuit)
+
 	// 注册默认提供商
 	if err := m.registerDefaultProviders(); err != nil {
 		return fmt.Errorf("注册默认提供商失败: %v", err)
@@ -56,31 +71,49 @@ func (m *ModelManager) init() error {
 	return nil
 }
 
+// registerDefaultProviders 按配置注册提供商，没有配置密钥的提供商不注册
 func (m *ModelManager) registerDefaultProviders() error {
-	// 注册豆包提供商
-	doubaoProvider, err := NewDoubaoProvider()
-	if err != nil {
-		return fmt.Errorf("创建豆包提供商失败: %v", err)
-	}
-	m.RegisterProvider(doubaoProvider)
+	cfg := config.GetConfig()
+	for _, providerConfig := range cfg.Providers {
+		if !providerConfig.Enabled() {
+			continue
+		}
 
-	// 注册DeepSeek提供商
-	deepseekProvider, err := NewDeepSeekProvider()
-	if err != nil {
-		return fmt.Errorf("创建DeepSeek提供商失败: %v", err)
+		provider, err := newProvider(providerConfig)
+		if err != nil {
+			return fmt.Errorf("创建提供商 %s 失败: %v", providerConfig.Name, err)
+		}
+		m.RegisterProvider(provider)
+		if len(providerConfig.Fallbacks) > 0 {
+			m.SetFallbacks(providerConfig.Name, providerConfig.Fallbacks)
+		}
 	}
-	m.RegisterProvider(deepseekProvider)
 
-	// 注册Gemini提供商
-	geminiProvider, err := NewGeminiProvider()
-	if err != nil {
-		return fmt.Errorf("创建Gemini提供商失败: %v", err)
+	// 配置了默认模型时优先使用
+	if cfg.DefaultModel != "" {
+		if _, exists := m.providers[cfg.DefaultModel]; exists {
+			m.currentProvider = m.providers[cfg.DefaultModel]
+		}
 	}
-	m.RegisterProvider(geminiProvider)
 
 	return nil
 }
 
+// newProvider 按提供商类型创建提供商
+func newProvider(cfg config.ProviderConfig) (ModelProvider, error) {
+	switch cfg.Type {
+	case config.ProviderTypeOpenAI:
+		return NewOpenAICompatibleProvider(cfg)
+	case config.ProviderTypeGemini:
+		return NewGeminiProvider(cfg)
+	case config.ProviderTypeFake:
+		return NewFakeProvider(cfg, nil)
+	case config.ProviderTypeReplay:
+		return NewReplayProvider(cfg)
+	}
+	return nil, fmt.Errorf("不支持的提供商类型: %s", cfg.Type)
+}
+
 // RegisterProvider 注册提供商
 func (m *ModelManager) RegisterProvider(provider ModelProvider) {
 	m.mu.Lock()
@@ -94,6 +127,20 @@ func (m *ModelManager) RegisterProvider(provider ModelProvider) {
 	}
 }
 
+// WrapProviders 用 wrap 包装所有已注册的提供商，例如录制调用
+func (m *ModelManager) WrapProviders(wrap func(ModelProvider) ModelProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, provider := range m.providers {
+		wrapped := wrap(provider)
+		if m.currentProvider == provider {
+			m.currentProvider = wrapped
+		}
+		m.providers[name] = wrapped
+	}
+}
+
 // SetCurrentProvider 设置当前提供商
 func (m *ModelManager) SetCurrentProvider(name string) error {
 	m.mu.Lock()
@@ -142,16 +189,64 @@ func (m *ModelManager) GetProviderDisplayNames() map[string]string {
 
 // CallCurrentModel 调用当前模型
 func (m *ModelManager) CallCurrentModel(ctx context.Context, systemPrompt, userPrompt string, options map[string]interface{}) (string, error) {
+	result, err := m.Call(ctx, systemPrompt, userPrompt, options)
+	return result.Output, err
+}
+
+// Call 调用当前模型，当前模型不可用时按配置的 fallbacks 依次换用其他提供商，结果中记录实际应答的提供商
+func (m *ModelManager) Call(ctx context.Context, systemPrompt, userPrompt string, options map[string]interface{}) (CallResult, error) {
 	m.mu.RLock()
 	provider := m.currentProvider
 	m.mu.RUnlock()
-	
+
 	if provider == nil {
-		return "", fmt.Errorf("没有设置当前模型")
+		return CallResult{}, fmt.Errorf("没有设置当前模型")
+	}
+
+	// 预算用尽时不再调用
+	if err := m.CheckBudget(ctx); err != nil {
+		return CallResult{}, err
+	}
+
+	policy := m.RetryPolicy()
+	result, err := m.callWithFallback(ctx, provider, func(ctx context.Context, provider ModelProvider) (string, error) {
+		// 限流、服务端错误和超时按重试策略重试
+		var output string
+		err := policy.Do(ctx, func() error {
+			var err error
+			// ctx 中有流式回调时改为流式调用
+			if handler := StreamHandlerFromContext(ctx); handler != nil {
+				output, err = streamProvider(ctx, provider, systemPrompt, userPrompt, handler)
+			} else {
+				output, err = provider.CallLLM(ctx, systemPrompt, userPrompt, options)
+			}
+			return err
+		})
+		return output, err
+	})
+	if err != nil {
+		return result, err
 	}
 
-	// 直接调用提供商
-	return provider.CallLLM(ctx, systemPrompt, userPrompt, options)
+	// 本次调用使预算超过上限时照常返回结果，同时返回 *BudgetError，调用方据此停止后续调用
+	if err := m.checkOverrun(ctx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// SetRetryPolicy 设置模型调用的重试策略
+func (m *ModelManager) SetRetryPolicy(policy RetryPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retry = policy
+}
+
+// RetryPolicy 获取模型调用的重试策略
+func (m *ModelManager) RetryPolicy() RetryPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.retry
 }
 
 // CallLLM 调用LLM（简化版本）
@@ -170,7 +265,7 @@ func (m *ModelManager) CallLLM(prompt string) (string, error) {
 	// 调用模型
 	response, err := provider.Generate(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("调用模型失败: %v", err)
+		return "", fmt.Errorf("调用模型失败: %w", err)
 	}
 
 	return response.Content, nil
@@ -188,6 +283,21 @@ func (m *ModelManager) UpdateStats(inputTokens, outputTokens, thinkingTokens int
 	m.stats.TotalCost += cost
 }
 
+// RecordUsage 把一次模型调用记入用量账本和会话预算，并更新统计信息
+func (m *ModelManager) RecordUsage(record UsageRecord) {
+	m.ledger.Append(record)
+	m.mu.RLock()
+	sessionBudget := m.sessionBudget
+	m.mu.RUnlock()
+	sessionBudget.add(record)
+	m.UpdateStats(record.InputTokens, record.OutputTokens, record.ThinkingTokens, record.Cost)
+}
+
+// Ledger 获取用量账本
+func (m *ModelManager) Ledger() *UsageLedger {
+	return m.ledger
+}
+
 // GetStats 获取统计信息
 func (m *ModelManager) GetStats() SessionStats {
 	m.mu.RLock()
@@ -196,12 +306,14 @@ func (m *ModelManager) GetStats() SessionStats {
 	return m.stats
 }
 
-// ResetStats 重置统计信息
+// ResetStats 重置统计信息、用量账本和会话预算
 func (m *ModelManager) ResetStats() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
 	m.stats = SessionStats{}
+	m.ledger.Reset()
+	m.sessionBudget = NewBudget(BudgetScopeSession, m.budget.Session)
 }
 
 // Cleanup 清理资源
@@ -0,0 +1,472 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sashabaranov/go-openai"
+	"loomi2.0/config"
+)
+
+// OpenAICompatibleProvider OpenAI 兼容接口的模型提供商，DeepSeek、豆包、Qwen、Moonshot、vLLM、Ollama 等都通过它接入
+type OpenAICompatibleProvider struct {
+	*BaseProvider
+	client *openai.Client
+	cfg    config.ProviderConfig
+	config map[string]interface{}
+}
+
+// NewOpenAICompatibleProvider 按配置创建 OpenAI 兼容提供商
+func NewOpenAICompatibleProvider(cfg config.ProviderConfig) (*OpenAICompatibleProvider, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("提供商 %s 缺少 base_url", cfg.Name)
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("提供商 %s 缺少 model", cfg.Name)
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.BaseURL = cfg.BaseURL
+	transport := http.DefaultTransport
+	if len(cfg.Headers) > 0 {
+		transport = &headerTransport{headers: cfg.Headers, base: transport}
+	}
+	clientConfig.HTTPClient = &http.Client{Transport: &retryAfterTransport{base: transport}}
+
+	client := openai.NewClientWithConfig(clientConfig)
+
+	// 创建基础提供商
+	baseProvider := NewBaseProvider(cfg.Name, cfg.DisplayName, nil)
+
+	provider := &OpenAICompatibleProvider{
+		BaseProvider: baseProvider,
+		client:       client,
+		cfg:          cfg,
+		config:       make(map[string]interface{}),
+	}
+
+	baseProvider.client = provider
+
+	return provider, nil
+}
+
+// headerTransport 为每个请求附加配置的额外请求头
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// retryAfterTransport 把响应中的 Retry-After 记录到请求 ctx 中，go-openai 的错误不包含响应头
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		if retryAfter, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+			*retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+	}
+	return resp, err
+}
+
+type retryAfterKey struct{}
+
+// withRetryAfter 返回记录 Retry-After 的 ctx，请求结束后从返回的指针读取
+func withRetryAfter(ctx context.Context) (context.Context, *time.Duration) {
+	retryAfter := new(time.Duration)
+	return context.WithValue(ctx, retryAfterKey{}, retryAfter), retryAfter
+}
+
+// providerError 把 go-openai 的错误转换为 ProviderError
+func (p *OpenAICompatibleProvider) providerError(err error, retryAfter time.Duration) error {
+	providerErr := &ProviderError{Provider: p.Name(), Kind: ErrorKindUnknown, RetryAfter: retryAfter, Err: err}
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		code := apiErr.Type
+		if apiErr.Code != nil {
+			code = fmt.Sprintf("%v %s", apiErr.Code, apiErr.Type)
+		}
+		providerErr.StatusCode = apiErr.HTTPStatusCode
+		providerErr.Kind = classifyError(apiErr.HTTPStatusCode, code, apiErr.Message)
+	case errors.As(err, &reqErr):
+		providerErr.StatusCode = reqErr.HTTPStatusCode
+		providerErr.Kind = classifyError(reqErr.HTTPStatusCode, "", string(reqErr.Body))
+	default:
+		if kind, ok := classifyTransportError(err); ok {
+			providerErr.Kind = kind
+		}
+	}
+	return providerErr
+}
+
+// contentFilteredError 模型因安全策略停止输出
+func contentFilteredError(provider string) error {
+	return &ProviderError{Provider: provider, Kind: ErrorKindContentFiltered, Err: errors.New("finish_reason=content_filter")}
+}
+
+// CalculateCost 计算费用
+func (p *OpenAICompatibleProvider) CalculateCost(inputTokens, outputTokens, thinkingTokens int) float64 {
+	inputCost := float64(inputTokens) / 1_000_000 * p.cfg.Pricing.InputPer1M
+	outputCost := float64(outputTokens+thinkingTokens) / 1_000_000 * p.cfg.Pricing.OutputPer1M
+	return inputCost + outputCost
+}
+
+// buildMessages 转换消息格式；不支持 system 消息的接口把 system 内容并入第一条用户消息
+func (p *OpenAICompatibleProvider) buildMessages(input []*schema.Message) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(input))
+	var pendingSystem []string
+	for _, msg := range input {
+		if msg.Role == schema.System && !p.cfg.SupportsSystemMessage() {
+			pendingSystem = append(pendingSystem, msg.Content)
+			continue
+		}
+		content := msg.Content
+		if msg.Role == schema.User && len(pendingSystem) > 0 {
+			content = strings.Join(append(pendingSystem, content), "\n\n")
+			pendingSystem = nil
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    string(msg.Role),
+			Content: content,
+		})
+	}
+	if len(pendingSystem) > 0 {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: strings.Join(pendingSystem, "\n\n"),
+		})
+	}
+	return messages
+}
+
+// Generate 实现BaseChatModel接口
+func (p *OpenAICompatibleProvider) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	started := time.Now()
+	requestCtx, retryAfter := withRetryAfter(ctx)
+	resp, err := p.client.CreateChatCompletion(requestCtx, openai.ChatCompletionRequest{
+		Model:    p.cfg.Model,
+		Messages: p.buildMessages(input),
+	})
+	if err != nil {
+		return nil, p.providerError(err, *retryAfter)
+	}
+
+	// 处理响应
+	if len(resp.Choices) == 0 {
+		return nil, &ProviderError{Provider: p.Name(), Kind: ErrorKindServer, Err: errors.New("API返回空响应")}
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+		return nil, contentFilteredError(p.Name())
+	}
+
+	content := resp.Choices[0].Message.Content
+	content = p.ProcessText(content)
+
+	// 更新统计信息，接口没有返回用量时本地估算
+	usage := openAIUsage(resp.Usage)
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		usage = UsageRecord{
+			InputTokens:  EstimateMessagesTokens(input),
+			OutputTokens: EstimateTokens(content),
+			Estimated:    true,
+		}
+	}
+	usage.Model = p.cfg.Model
+	recordUsage(ctx, p, started, usage)
+
+	return &schema.Message{
+		Role:    "assistant",
+		Content: content,
+	}, nil
+}
+
+// Stream 实现BaseChatModel接口；不支持流式的接口一次性返回完整结果
+func (p *OpenAICompatibleProvider) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if !p.cfg.SupportsStream() {
+		msg, err := p.Generate(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+	}
+
+	request := openai.ChatCompletionRequest{
+		Model:    p.cfg.Model,
+		Messages: p.buildMessages(input),
+	}
+	if p.cfg.SupportsStreamUsage() {
+		request.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	requestCtx, retryAfter := withRetryAfter(ctx)
+	stream, err := p.client.CreateChatCompletionStream(requestCtx, request)
+	if err != nil {
+		return nil, p.providerError(err, *retryAfter)
+	}
+
+	// 创建一个适配器来转换流
+	streamReader := &OpenAIStreamReader{stream: stream, provider: p.Name()}
+
+	// 创建一个简单的流适配器
+	reader, writer := schema.Pipe[*schema.Message](5)
+
+	usage := newStreamUsage(ctx, p, p.cfg.Model, input)
+	think := &thinkBlockFilter{}
+
+	go func() {
+		defer writer.Close()
+		defer streamReader.Close()
+
+		for {
+			msg, err := streamReader.Recv()
+			if err != nil {
+				if streamReader.usage != nil {
+					usage.Report(openAIUsage(*streamReader.usage))
+				}
+				usage.Finish(err)
+				if rest := think.Flush(); rest != "" {
+					writer.Send(schema.AssistantMessage(rest, nil), nil)
+				}
+				var providerErr *ProviderError
+				if err != io.EOF && !errors.As(err, &providerErr) {
+					err = p.providerError(err, 0)
+				}
+				writer.Send(msg, err)
+				break
+			}
+			// 思考过程同样计费，用量按原始输出统计
+			if msg.Content != "" {
+				usage.Add(msg.Content)
+				if msg.Content = think.Write(msg.Content); msg.Content == "" && len(msg.ToolCalls) == 0 {
+					continue
+				}
+			}
+			writer.Send(msg, nil)
+		}
+	}()
+
+	return reader, nil
+}
+
+// openAIUsage 转换接口返回的用量，推理 token 从输出中拆出单独记为思考 token
+func openAIUsage(u openai.Usage) UsageRecord {
+	usage := UsageRecord{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.ThinkingTokens = u.CompletionTokensDetails.ReasoningTokens
+		usage.OutputTokens -= usage.ThinkingTokens
+	}
+	return usage
+}
+
+// GetInputType 获取输入类型
+func (p *OpenAICompatibleProvider) GetInputType() string {
+	return "message"
+}
+
+// GetOutputType 获取输出类型
+func (p *OpenAICompatibleProvider) GetOutputType() string {
+	return "message"
+}
+
+// GetOptionType 获取选项类型
+func (p *OpenAICompatibleProvider) GetOptionType() string {
+	return "config"
+}
+
+// SetOption 设置选项
+func (p *OpenAICompatibleProvider) SetOption(option any) error {
+	if config, ok := option.(map[string]interface{}); ok {
+		p.config = config
+		return nil
+	}
+	return fmt.Errorf("invalid option type")
+}
+
+// GetOption 获取选项
+func (p *OpenAICompatibleProvider) GetOption() any {
+	return p.config
+}
+
+// SetCallbacks 设置回调
+func (p *OpenAICompatibleProvider) SetCallbacks(callbacks callbacks.Handler) error {
+	return nil
+}
+
+// GetCallbacks 获取回调
+func (p *OpenAICompatibleProvider) GetCallbacks() callbacks.Handler {
+	return nil
+}
+
+// thinkBlockPattern 部分推理模型（例如 Ollama、vLLM 部署的 DeepSeek-R1）在 content 开头输出的思考过程
+var thinkBlockPattern = regexp.MustCompile(`(?s)^\s*<think>.*?</think>`)
+
+// ProcessText 处理完整的应答文本：去掉推理模型附带的 <think> 块和首尾空白，不改动其余内容，编排器依赖引号解析标签属性
+func (p *OpenAICompatibleProvider) ProcessText(text string) string {
+	return strings.TrimSpace(thinkBlockPattern.ReplaceAllString(text, ""))
+}
+
+// thinkBlockFilter 流式输出时去掉开头的 <think> 块和紧随其后的空白，与 ProcessText 的处理一致；
+// 标签可能被拆在多个分片中，无法确定时先缓存
+type thinkBlockFilter struct {
+	buf   string
+	state thinkFilterState
+}
+
+type thinkFilterState int
+
+const (
+	thinkDetecting thinkFilterState = iota // 输出开头，判断是否为 <think> 块
+	thinkInside                            // 在 <think> 块中
+	thinkSkipping                          // 思考块刚结束，去掉紧随其后的空白
+	thinkPassing                           // 原样输出
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// Write 处理一段输出，返回可以输出的部分
+func (f *thinkBlockFilter) Write(chunk string) string {
+	if f.state == thinkPassing {
+		return chunk
+	}
+	f.buf += chunk
+
+	switch f.state {
+	case thinkDetecting:
+		trimmed := strings.TrimLeftFunc(f.buf, unicode.IsSpace)
+		if trimmed == "" || strings.HasPrefix(thinkOpenTag, trimmed) {
+			return ""
+		}
+		if !strings.HasPrefix(trimmed, thinkOpenTag) {
+			f.buf = trimmed
+			return f.release()
+		}
+		f.buf = trimmed[len(thinkOpenTag):]
+		f.state = thinkInside
+		fallthrough
+	case thinkInside:
+		i := strings.Index(f.buf, thinkCloseTag)
+		if i < 0 {
+			return ""
+		}
+		f.buf = f.buf[i+len(thinkCloseTag):]
+		f.state = thinkSkipping
+		fallthrough
+	case thinkSkipping:
+		f.buf = strings.TrimLeftFunc(f.buf, unicode.IsSpace)
+		if f.buf == "" {
+			return ""
+		}
+		return f.release()
+	}
+	return ""
+}
+
+// release 输出缓存并转为原样输出
+func (f *thinkBlockFilter) release() string {
+	out := f.buf
+	f.buf = ""
+	f.state = thinkPassing
+	return out
+}
+
+// Flush 流结束时输出缓存中剩余的内容；没有闭合的 <think> 与 ProcessText 一样原样保留
+func (f *thinkBlockFilter) Flush() string {
+	out := ""
+	switch f.state {
+	case thinkDetecting:
+		out = strings.TrimSpace(f.buf)
+	case thinkInside:
+		out = thinkOpenTag + f.buf
+	}
+	f.buf = ""
+	f.state = thinkPassing
+	return out
+}
+
+// CallLLM 调用LLM（兼容原有接口）
+func (p *OpenAICompatibleProvider) CallLLM(ctx context.Context, systemPrompt, userPrompt string, options map[string]interface{}) (string, error) {
+	// 构建消息
+	messages := []*schema.Message{}
+
+	if systemPrompt != "" {
+		messages = append(messages, schema.SystemMessage(systemPrompt))
+	}
+
+	messages = append(messages, schema.UserMessage(userPrompt))
+
+	// 调用模型
+	response, err := p.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("调用模型失败: %w", err)
+	}
+
+	return response.Content, nil
+}
+
+// OpenAIStreamReader OpenAI 兼容接口的流读取器
+type OpenAIStreamReader struct {
+	stream   *openai.ChatCompletionStream
+	usage    *openai.Usage // 开启 include_usage 时最后一个分片返回的用量
+	provider string
+}
+
+func (r *OpenAIStreamReader) Recv() (*schema.Message, error) {
+	chunk, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if chunk.Usage != nil {
+		r.usage = chunk.Usage
+	}
+
+	if len(chunk.Choices) == 0 {
+		return &schema.Message{Role: "assistant"}, nil
+	}
+
+	if chunk.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+		return nil, contentFilteredError(r.provider)
+	}
+
+	content := chunk.Choices[0].Delta.Content
+	return &schema.Message{
+		Role:    "assistant",
+		Content: content,
+	}, nil
+}
+
+func (r *OpenAIStreamReader) Close() {
+	r.stream.Close()
+}
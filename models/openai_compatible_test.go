@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"loomi2.0/config"
+)
+
+func TestOpenAICompatibleProcessText(t *testing.T) {
+	provider, err := NewOpenAICompatibleProvider(config.ProviderConfig{Name: "local", BaseURL: "http://localhost:11434/v1", Model: "deepseek-r1", NoAuth: true})
+	if err != nil {
+		t.Fatalf("创建提供商失败: %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`<execute_step action="insight" instruction="分析"/>`, `<execute_step action="insight" instruction="分析"/>`},
+		{"<think>\n先想想\n</think>\n\n你好", "你好"},
+		{"  你好 \n", "你好"},
+	}
+	for _, tt := range tests {
+		if got := provider.ProcessText(tt.input); got != tt.want {
+			t.Errorf("ProcessText(%q) = %q，期望 %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestThinkBlockFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{name: "标签被拆开", chunks: []string{"<thi", "nk>\n先想", "想</th", "ink>", "\n\n", "你好"}, want: "你好"},
+		{name: "没有思考块", chunks: []string{"  <execute_step ", `action="insight"/>`, "\n"}, want: `<execute_step action="insight"/>` + "\n"},
+		{name: "开头只有空白", chunks: []string{" ", "\n", "你好"}, want: "你好"},
+		{name: "思考块没有闭合", chunks: []string{"<think>", "还在想"}, want: "<think>还在想"},
+		{name: "只有标签前缀", chunks: []string{"<th"}, want: "<th"},
+		{name: "思考块后面再出现标签", chunks: []string{"<think>a</think>", " ", "<think>b</think>"}, want: "<think>b</think>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &thinkBlockFilter{}
+			var got strings.Builder
+			for _, chunk := range tt.chunks {
+				got.WriteString(filter.Write(chunk))
+			}
+			got.WriteString(filter.Flush())
+			if got.String() != tt.want {
+				t.Errorf("输出 %q，期望 %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAICompatibleStreamHidesThinkBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"<think>", "先想想", "</think>\n", "你", "好"} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"created\":0,\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q},\"finish_reason\":null}]}\n\n", content)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAICompatibleProvider(config.ProviderConfig{Name: "local", BaseURL: server.URL, Model: "deepseek-r1", NoAuth: true})
+	if err != nil {
+		t.Fatalf("创建提供商失败: %v", err)
+	}
+	reader, err := provider.Stream(context.Background(), []*schema.Message{schema.UserMessage("你好")})
+	if err != nil {
+		t.Fatalf("流式调用失败: %v", err)
+	}
+	defer reader.Close()
+
+	var got strings.Builder
+	for {
+		msg, err := reader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取流失败: %v", err)
+		}
+		got.WriteString(msg.Content)
+	}
+	if got.String() != "你好" {
+		t.Errorf("流式输出应当去掉思考块，实际 %q", got.String())
+	}
+}
@@ -82,7 +82,7 @@ func (p *BaseProvider) CallLLM(ctx context.Context, systemPrompt, userPrompt str
 	// 调用模型
 	response, err := p.Generate(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("调用模型失败: %v", err)
+		return "", fmt.Errorf("调用模型失败: %w", err)
 	}
 
 	return response.Content, nil
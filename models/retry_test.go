@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"loomi2.0/config"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	fake, err := NewFakeProvider(config.ProviderConfig{Name: "fake", Type: config.ProviderTypeFake}, &FakeScript{
+		Rules: []*FakeRule{
+			{Name: "rate-limited", User: "限流", Error: "请求过于频繁", ErrorKind: ErrorKindRateLimited, RetryAfter: 5 * time.Millisecond, Times: 1},
+			{Name: "auth", User: "鉴权", Error: "密钥无效", ErrorKind: ErrorKindAuth},
+		},
+		Default: "成功",
+	})
+	if err != nil {
+		t.Fatalf("创建 fake 提供商失败: %v", err)
+	}
+
+	var retries []time.Duration
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Second,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			retries = append(retries, delay)
+		},
+	}
+	ctx := context.Background()
+
+	var output string
+	err = policy.Do(ctx, func() error {
+		var err error
+		output, err = fake.CallLLM(ctx, "系统", "限流", nil)
+		return err
+	})
+	if err != nil || output != "成功" {
+		t.Fatalf("重试后应当成功，实际 %q (%v)", output, err)
+	}
+	if len(retries) != 1 || retries[0] < 5*time.Millisecond {
+		t.Errorf("应当按 Retry-After 重试一次，实际 %v", retries)
+	}
+
+	retries = nil
+	err = policy.Do(ctx, func() error {
+		_, err := fake.CallLLM(ctx, "系统", "鉴权", nil)
+		return err
+	})
+	if ErrorKindOf(err) != ErrorKindAuth || len(retries) != 0 {
+		t.Errorf("鉴权错误不应重试: %v，重试 %d 次", err, len(retries))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "秒数", value: "5", want: 5 * time.Second},
+		{name: "小数秒", value: "0.5", want: 500 * time.Millisecond},
+		{name: "前后空白", value: " 2 ", want: 2 * time.Second},
+		{name: "空值", value: "", want: 0},
+		{name: "零", value: "0", want: 0},
+		{name: "负数", value: "-3", want: 0},
+		{name: "无法解析", value: "soon", want: 0},
+		{name: "过去的日期", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("解析 %q 期望 %v，实际 %v", tt.value, tt.want, got)
+			}
+		})
+	}
+
+	// HTTP 日期只精确到秒
+	value := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(value); got < 28*time.Second || got > 30*time.Second {
+		t.Errorf("解析 HTTP 日期 %q 期望约 30s，实际 %v", value, got)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	serverErr := &ProviderError{Provider: "fake", Kind: ErrorKindServer}
+
+	// 指数退避加等量抖动，不超过上限
+	for attempt, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second} {
+		delay, retry := policy.Delay(attempt, serverErr)
+		if !retry || delay < limit/2 || delay > limit {
+			t.Errorf("第 %d 次失败后应当等待 [%v, %v]，实际 %v (%v)", attempt, limit/2, limit, delay, retry)
+		}
+	}
+	if _, retry := policy.Delay(6, serverErr); retry {
+		t.Error("达到最多调用次数后不应重试")
+	}
+
+	// Retry-After 比退避时间长时按 Retry-After 等待，超过上限时不再重试
+	if delay, retry := policy.Delay(1, &ProviderError{Kind: ErrorKindRateLimited, RetryAfter: 800 * time.Millisecond}); !retry || delay != 800*time.Millisecond {
+		t.Errorf("应当按 Retry-After 等待 800ms，实际 %v (%v)", delay, retry)
+	}
+	if _, retry := policy.Delay(1, &ProviderError{Kind: ErrorKindRateLimited, RetryAfter: 2 * time.Second}); retry {
+		t.Error("Retry-After 超过等待上限时不应重试")
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// StreamHandler 流式输出回调，每收到一段模型输出调用一次
+type StreamHandler func(chunk string)
+
+type streamHandlerKey struct{}
+
+// WithStreamHandler 返回携带流式回调的 ctx；经过该 ctx 的模型调用改为流式，并把每段输出交给 handler
+func WithStreamHandler(ctx context.Context, handler StreamHandler) context.Context {
+	return context.WithValue(ctx, streamHandlerKey{}, handler)
+}
+
+// StreamHandlerFromContext 获取 ctx 中的流式回调，没有时返回 nil
+func StreamHandlerFromContext(ctx context.Context) StreamHandler {
+	handler, _ := ctx.Value(streamHandlerKey{}).(StreamHandler)
+	return handler
+}
+
+// streamProvider 以流式方式调用提供商，把每段输出交给 handler，返回完整输出
+func streamProvider(ctx context.Context, provider ModelProvider, systemPrompt, userPrompt string, handler StreamHandler) (string, error) {
+	messages := []*schema.Message{}
+	if systemPrompt != "" {
+		messages = append(messages, schema.SystemMessage(systemPrompt))
+	}
+	messages = append(messages, schema.UserMessage(userPrompt))
+
+	stream, err := provider.Stream(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("调用模型失败: %w", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if content.Len() > 0 {
+				// 已经输出的内容无法撤回，不再重试
+				err = &streamInterruptedError{err: err}
+			}
+			return "", fmt.Errorf("读取模型输出失败: %w", err)
+		}
+		if chunk == nil || chunk.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Content)
+		handler(chunk.Content)
+	}
+	return content.String(), nil
+}
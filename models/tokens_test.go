@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "空文本", text: "", want: 0},
+		{name: "纯中文", text: "小红书笔记", want: 5},
+		{name: "中文和全角标点", text: "早八人，冲！", want: 6},
+		{name: "日文和韩文", text: "ありがとう감사", want: 7},
+		{name: "纯英文", text: "hello world!", want: 3},
+		{name: "不足四个字符按一个算", text: "ok", want: 1},
+		{name: "中英混合", text: "写一篇 coffee 笔记，", want: 8},
+		{name: "中文夹数字", text: "3个效率神器", want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("估算 %q 期望 %d，实际 %d", tt.text, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimateMessagesTokens(t *testing.T) {
+	messages := []*schema.Message{schema.SystemMessage("你是编排员"), schema.UserMessage("")}
+	if got, want := EstimateMessagesTokens(messages), 5+2*messageOverheadTokens; got != want {
+		t.Errorf("期望 %d，实际 %d", want, got)
+	}
+}
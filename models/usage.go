@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// messageOverheadTokens 每条消息中角色、分隔符等格式占用的 token
+const messageOverheadTokens = 4
+
+// EstimateTokens 本地估算文本的 token 数：中日韩文字与全角标点约一字一个 token，其余文字约四个字符一个 token
+func EstimateTokens(text string) int {
+	wide, other := 0, 0
+	for _, r := range text {
+		if isWideRune(r) {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
+
+// isWideRune 是否为中日韩文字或全角标点
+func isWideRune(r rune) bool {
+	switch {
+	case r >= 0x3000 && r <= 0x303F, r >= 0xFF00 && r <= 0xFFEF:
+		return true
+	}
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// EstimateMessagesTokens 估算一组输入消息的 token 数
+func EstimateMessagesTokens(messages []*schema.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(msg.Content) + messageOverheadTokens
+	}
+	return total
+}
+
+// recordUsage 按提供商价格计费，补全归属标签和耗时后记入用量账本，并计入 ctx 中的任务预算；
+// 已带费用的记录（如回放录制的调用）沿用原费用
+func recordUsage(ctx context.Context, provider ModelProvider, started time.Time, record UsageRecord) {
+	tags := UsageTagsFromContext(ctx)
+	record.Time = started
+	record.Latency = time.Since(started)
+	record.Session = tags.Session
+	record.Agent = tags.Agent
+	record.Action = tags.Action
+	record.Provider = provider.Name()
+	record.FallbackFrom = fallbackFromContext(ctx)
+	if record.Model == "" {
+		record.Model = provider.Name()
+	}
+	if record.Cost == 0 {
+		record.Cost = provider.CalculateCost(record.InputTokens, record.OutputTokens, record.ThinkingTokens)
+	}
+	if observe, _ := ctx.Value(usageObserverKey{}).(func(UsageRecord)); observe != nil {
+		observe(record)
+	}
+
+	modelManager := GetModelManager()
+	if modelManager == nil {
+		return
+	}
+	modelManager.RecordUsage(record)
+	if budget := BudgetFromContext(ctx); budget != nil {
+		budget.add(record)
+	}
+}
+
+type usageObserverKey struct{}
+
+// withUsageObserver 返回 ctx，经过该 ctx 的调用记账时同时交给 observe，例如录制到磁带
+func withUsageObserver(ctx context.Context, observe func(UsageRecord)) context.Context {
+	return context.WithValue(ctx, usageObserverKey{}, observe)
+}
+
+// streamUsage 累计一次流式调用的输出，结束时优先使用接口返回的用量记账，没有时本地估算
+type streamUsage struct {
+	ctx      context.Context
+	provider ModelProvider
+	model    string
+	input    []*schema.Message
+	started  time.Time
+	output   strings.Builder
+	usage    *UsageRecord
+}
+
+func newStreamUsage(ctx context.Context, provider ModelProvider, model string, input []*schema.Message) *streamUsage {
+	return &streamUsage{ctx: ctx, provider: provider, model: model, input: input, started: time.Now()}
+}
+
+// Add 累计一段输出
+func (u *streamUsage) Add(content string) {
+	u.output.WriteString(content)
+}
+
+// Report 记录接口返回的用量
+func (u *streamUsage) Report(usage UsageRecord) {
+	u.usage = &usage
+}
+
+// Finish 流结束时记账，err 为读取流时的错误；出错且没有任何输出的调用与同步调用失败一样不计入
+func (u *streamUsage) Finish(err error) {
+	if err != nil && err != io.EOF && u.usage == nil && u.output.Len() == 0 {
+		return
+	}
+	record := UsageRecord{
+		InputTokens:  EstimateMessagesTokens(u.input),
+		OutputTokens: EstimateTokens(u.output.String()),
+		Estimated:    true,
+	}
+	if u.usage != nil {
+		record = *u.usage
+	}
+	record.Model = u.model
+	record.Stream = true
+	recordUsage(u.ctx, u.provider, u.started, record)
+}
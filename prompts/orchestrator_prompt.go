@@ -24,7 +24,8 @@ const OrchestratorPrompt = `你是Loomi，一个社媒内容研究与生产的
 - profile：寻找受众画像以及他们的生存场景中的痛点、偏见等。
 - brand_analysis：从用户角度分析本品和竞品，也可对比分析（输入1~3个品牌名和需求）
 - hitpoint：探索不同视角下的选题策略。
-- content_analysis：拆解一篇社媒内容（小红书、公众号、抖音脚本等等）的质量与技巧。
+- xhs_style：设计小红书帖子的文体风格与阅读体验，产出style类notes。
+- content_analysis：拆解一篇社媒内容（小红书、公众号、抖音脚本等等）的质量与技巧。可以@material引用，也可以在instruction中直接粘贴原文。
 - websearch：搜索公域互联网信息，主要用于搜索超出你知识范围(2025年1月)的时事新闻、你不知道的品牌、产品、事件、人物等。无法搜索社媒平台（如小红书、抖音、公众号）上的内容。
 
 ### 写作类：
@@ -6,44 +6,6 @@ import (
 	"loomi2.0/models"
 )
 
-// TestWorkspace 测试工作空间
-func TestWorkspace(t *testing.T) {
-	// 初始化工作空间
-	err := core.InitWorkspace()
-	if err != nil {
-		t.Fatalf("初始化工作空间失败: %v", err)
-	}
-
-	workspace := core.GetWorkspace()
-	if workspace == nil {
-		t.Fatal("获取工作空间失败")
-	}
-
-	// 测试添加笔记
-	workspace.AddNote("测试笔记")
-	notes := workspace.GetNotes()
-	if len(notes) != 1 {
-		t.Errorf("笔记数量错误，期望 1，实际 %d", len(notes))
-	}
-
-	// 测试添加任务
-	workspace.AddTask("测试任务")
-	tasks := workspace.GetTasks()
-	if len(tasks) != 1 {
-		t.Errorf("任务数量错误，期望 1，实际 %d", len(tasks))
-	}
-
-	// 测试上下文
-	workspace.SetContext("test_key", "test_value")
-	value, exists := workspace.GetContext("test_key")
-	if !exists {
-		t.Error("上下文设置失败")
-	}
-	if value != "test_value" {
-		t.Errorf("上下文值错误，期望 test_value，实际 %v", value)
-	}
-}
-
 // TestConversationManager 测试对话管理器
 func TestConversationManager(t *testing.T) {
 	// 初始化对话管理器
@@ -76,6 +38,9 @@ func TestConversationManager(t *testing.T) {
 
 // TestModelManager 测试模型管理器
 func TestModelManager(t *testing.T) {
+	// 没有配置密钥的提供商不会注册
+	t.Setenv("LOOMI_DEEPSEEK_API_KEY", "test-key")
+
 	// 初始化模型管理器
 	err := models.InitModelManager()
 	if err != nil {
@@ -102,7 +67,6 @@ func TestModelManager(t *testing.T) {
 
 // TestBasicFunctionality 测试基本功能
 func TestBasicFunctionality(t *testing.T) {
-	t.Run("工作空间测试", TestWorkspace)
 	t.Run("对话管理器测试", TestConversationManager)
 	t.Run("模型管理器测试", TestModelManager)
 } 
\ No newline at end of file
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"loomi2.0/cassette"
+)
+
+// RecordingTool 把工具调用录制到磁带的包装，调用本身仍交给被包装的工具
+type RecordingTool struct {
+	Tool
+	cassette *cassette.Cassette
+}
+
+// RecordingSearchTool 同时录制结构化搜索的包装
+type RecordingSearchTool struct {
+	*RecordingTool
+	search SearchTool
+}
+
+// NewRecordingTool 创建录制包装；被包装的工具支持结构化搜索时返回的包装也支持
+func NewRecordingTool(tool Tool, c *cassette.Cassette) Tool {
+	recording := &RecordingTool{Tool: tool, cassette: c}
+	if search, ok := tool.(SearchTool); ok {
+		return &RecordingSearchTool{RecordingTool: recording, search: search}
+	}
+	return recording
+}
+
+// record 录制一次工具调用
+func (t *RecordingTool) record(method, input, output string, data json.RawMessage, callErr error) error {
+	in := &cassette.Interaction{
+		Kind:   cassette.KindTool,
+		Name:   t.Name(),
+		Method: method,
+		Key:    cassette.ToolKey(t.Name(), method, input),
+		Input:  input,
+		Output: output,
+		Data:   data,
+	}
+	if callErr != nil {
+		in.Error = callErr.Error()
+	}
+	return t.cassette.Record(in)
+}
+
+// Execute 执行被包装的工具并录制结果
+func (t *RecordingTool) Execute(ctx context.Context, input string) (string, error) {
+	output, err := t.Tool.Execute(ctx, input)
+	if recordErr := t.record("execute", input, output, nil, err); recordErr != nil {
+		return "", recordErr
+	}
+	return output, err
+}
+
+// Search 执行结构化搜索并录制结果
+func (t *RecordingSearchTool) Search(ctx context.Context, query string) (*SearchResponse, error) {
+	response, err := t.search.Search(ctx, query)
+	var data json.RawMessage
+	if response != nil {
+		var marshalErr error
+		if data, marshalErr = json.Marshal(response); marshalErr != nil {
+			return nil, fmt.Errorf("序列化搜索结果失败: %v", marshalErr)
+		}
+	}
+	if recordErr := t.record("search", query, "", data, err); recordErr != nil {
+		return nil, recordErr
+	}
+	return response, err
+}
+
+// ReplayTool 从磁带回放调用的工具，不访问任何网络
+type ReplayTool struct {
+	name     string
+	cassette *cassette.Cassette
+}
+
+// NewReplayTool 创建回放工具，name 为录制时的工具名称
+func NewReplayTool(name string, c *cassette.Cassette) *ReplayTool {
+	return &ReplayTool{name: name, cassette: c}
+}
+
+// Name 工具名称
+func (t *ReplayTool) Name() string {
+	return t.name
+}
+
+// Description 工具描述
+func (t *ReplayTool) Description() string {
+	return fmt.Sprintf("回放磁带中录制的 %s 调用", t.name)
+}
+
+// next 取出与请求匹配的录制结果
+func (t *ReplayTool) next(method, input string) (*cassette.Interaction, error) {
+	in, err := t.cassette.Next(cassette.ToolKey(t.name, method, input))
+	if err != nil {
+		return nil, fmt.Errorf("%s 回放失败: %v", t.name, err)
+	}
+	// 搜索工具的错误都是纯文本，没有类别或 Retry-After 需要恢复
+	if in.Error != "" {
+		return nil, errors.New(in.Error)
+	}
+	return in, nil
+}
+
+// Execute 返回录制的工具输出
+func (t *ReplayTool) Execute(ctx context.Context, input string) (string, error) {
+	in, err := t.next("execute", input)
+	if err != nil {
+		return "", err
+	}
+	return in.Output, nil
+}
+
+// Search 返回录制的结构化搜索结果
+func (t *ReplayTool) Search(ctx context.Context, query string) (*SearchResponse, error) {
+	in, err := t.next("search", query)
+	if err != nil {
+		return nil, err
+	}
+	response := &SearchResponse{}
+	if err := json.Unmarshal(in.Data, response); err != nil {
+		return nil, fmt.Errorf("解析录制的搜索结果失败: %v", err)
+	}
+	return response, nil
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 )
 
 // Tool 工具接口
@@ -11,6 +12,12 @@ type Tool interface {
 	Execute(ctx context.Context, input string) (string, error)
 }
 
+// SearchTool 支持返回结构化结果的搜索工具
+type SearchTool interface {
+	Tool
+	Search(ctx context.Context, query string) (*SearchResponse, error)
+}
+
 // SearchResult 搜索结果
 type SearchResult struct {
 	Title       string `json:"title"`
@@ -26,4 +33,19 @@ type SearchResponse struct {
 	Results []SearchResult `json:"results"`
 	Total   int            `json:"total"`
 	Source  string         `json:"source"` // "serper" 或 "tavily"
-} 
\ No newline at end of file
+}
+
+// FormatSearchResponse 把结构化搜索结果格式化为展示文本
+func FormatSearchResponse(response *SearchResponse, sourceName string) string {
+	output := fmt.Sprintf("🔍 %s搜索结果 - 查询: %s\n\n", sourceName, response.Query)
+	for i, result := range response.Results {
+		output += fmt.Sprintf("%d. **%s**\n", i+1, result.Title)
+		output += fmt.Sprintf("   %s\n", result.Snippet)
+		output += fmt.Sprintf("   链接: %s\n", result.URL)
+		if result.PublishedAt != "" {
+			output += fmt.Sprintf("   发布时间: %s\n", result.PublishedAt)
+		}
+		output += "\n"
+	}
+	return output
+}
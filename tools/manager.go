@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+
+	"loomi2.0/config"
 )
 
 // ToolManager 工具管理器
@@ -11,6 +14,37 @@ type ToolManager struct {
 	tools map[string]Tool
 }
 
+var toolManager *ToolManager
+var toolManagerOnce sync.Once
+
+// InitToolManager 初始化全局工具管理器，按配置注册搜索工具；没有配置密钥的工具不注册
+func InitToolManager() error {
+	var err error
+	toolManagerOnce.Do(func() {
+		toolManager = NewToolManager()
+		for _, toolConfig := range config.GetConfig().Tools {
+			if toolConfig.APIKey == "" {
+				continue
+			}
+			switch toolConfig.Name {
+			case "serper":
+				toolManager.RegisterTool(NewSerperTool(toolConfig.APIKey, toolConfig.Endpoint))
+			case "tavily":
+				toolManager.RegisterTool(NewTavilyTool(toolConfig.APIKey, toolConfig.Endpoint))
+			default:
+				err = fmt.Errorf("不支持的工具: %s", toolConfig.Name)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// GetToolManager 获取全局工具管理器实例
+func GetToolManager() *ToolManager {
+	return toolManager
+}
+
 // NewToolManager 创建工具管理器
 func NewToolManager() *ToolManager {
 	return &ToolManager{
@@ -23,6 +57,13 @@ func (tm *ToolManager) RegisterTool(tool Tool) {
 	tm.tools[tool.Name()] = tool
 }
 
+// WrapTools 用 wrap 包装所有已注册的工具，例如录制调用
+func (tm *ToolManager) WrapTools(wrap func(Tool) Tool) {
+	for name, tool := range tm.tools {
+		tm.tools[name] = wrap(tool)
+	}
+}
+
 // GetTool 获取工具
 func (tm *ToolManager) GetTool(name string) (Tool, bool) {
 	tool, exists := tm.tools[name]
@@ -126,4 +167,44 @@ func (tm *ToolManager) PerformDualSearch(ctx context.Context, query string) (str
 	}
 	
 	return strings.Join(results, "\n\n" + strings.Repeat("=", 50) + "\n\n"), nil
-} 
\ No newline at end of file
+}
+
+// PerformStructuredSearch 执行搜索并返回结构化结果；toolName 为空时使用所有搜索工具（同 PerformDualSearch）
+func (tm *ToolManager) PerformStructuredSearch(ctx context.Context, query, toolName string) ([]*SearchResponse, error) {
+	names := []string{"serper_search", "tavily_search"}
+	if toolName != "" {
+		names = []string{toolName}
+	}
+
+	var (
+		responses []*SearchResponse
+		errs      []string
+	)
+	for _, name := range names {
+		tool, exists := tm.tools[name]
+		if !exists {
+			if toolName != "" {
+				return nil, fmt.Errorf("工具 %s 不存在", toolName)
+			}
+			continue
+		}
+		searchTool, ok := tool.(SearchTool)
+		if !ok {
+			return nil, fmt.Errorf("工具 %s 不支持结构化搜索", name)
+		}
+		response, err := searchTool.Search(ctx, query)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		responses = append(responses, response)
+	}
+
+	if len(responses) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("搜索失败: %s", strings.Join(errs, "; "))
+		}
+		return nil, fmt.Errorf("没有可用的搜索工具")
+	}
+	return responses, nil
+}
@@ -12,14 +12,22 @@ import (
 
 // SerperTool Serper搜索工具
 type SerperTool struct {
-	apiKey string
-	client *http.Client
+	apiKey   string
+	endpoint string
+	client   *http.Client
 }
 
-// NewSerperTool 创建Serper工具实例
-func NewSerperTool(apiKey string) *SerperTool {
+// DefaultSerperEndpoint Serper 默认的搜索接口地址
+const DefaultSerperEndpoint = "https://google.serper.dev/search"
+
+// NewSerperTool 创建Serper工具实例，endpoint 为空时使用默认地址
+func NewSerperTool(apiKey, endpoint string) *SerperTool {
+	if endpoint == "" {
+		endpoint = DefaultSerperEndpoint
+	}
 	return &SerperTool{
-		apiKey: apiKey,
+		apiKey:   apiKey,
+		endpoint: endpoint,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -38,6 +46,15 @@ func (s *SerperTool) Description() string {
 
 // Execute 执行搜索
 func (s *SerperTool) Execute(ctx context.Context, query string) (string, error) {
+	response, err := s.Search(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	return FormatSearchResponse(response, "Serper"), nil
+}
+
+// Search 执行搜索并返回结构化结果
+func (s *SerperTool) Search(ctx context.Context, query string) (*SearchResponse, error) {
 	// 构建请求
 	requestBody := map[string]interface{}{
 		"q": query,
@@ -46,13 +63,13 @@ func (s *SerperTool) Execute(ctx context.Context, query string) (string, error)
 	
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %v", err)
+		return nil, fmt.Errorf("序列化请求失败: %v", err)
 	}
 	
 	// 创建HTTP请求
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://google.serper.dev/search", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %v", err)
+		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
@@ -61,18 +78,18 @@ func (s *SerperTool) Execute(ctx context.Context, query string) (string, error)
 	// 发送请求
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %v", err)
+		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 	
 	// 读取响应
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %v", err)
+		return nil, fmt.Errorf("读取响应失败: %v", err)
 	}
 	
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
 	}
 	
 	// 解析响应
@@ -85,7 +102,7 @@ func (s *SerperTool) Execute(ctx context.Context, query string) (string, error)
 	}
 	
 	if err := json.Unmarshal(body, &serperResponse); err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		return nil, fmt.Errorf("解析响应失败: %v", err)
 	}
 	
 	// 构建搜索结果
@@ -99,13 +116,10 @@ func (s *SerperTool) Execute(ctx context.Context, query string) (string, error)
 		})
 	}
 	
-	// 格式化输出
-	output := fmt.Sprintf("🔍 Serper搜索结果 - 查询: %s\n\n", query)
-	for i, result := range results {
-		output += fmt.Sprintf("%d. **%s**\n", i+1, result.Title)
-		output += fmt.Sprintf("   %s\n", result.Snippet)
-		output += fmt.Sprintf("   链接: %s\n\n", result.URL)
-	}
-	
-	return output, nil
-} 
\ No newline at end of file
+	return &SearchResponse{
+		Query:   query,
+		Results: results,
+		Total:   len(results),
+		Source:  "serper",
+	}, nil
+}
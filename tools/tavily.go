@@ -12,14 +12,22 @@ import (
 
 // TavilyTool Tavily搜索工具
 type TavilyTool struct {
-	apiKey string
-	client *http.Client
+	apiKey   string
+	endpoint string
+	client   *http.Client
 }
 
-// NewTavilyTool 创建Tavily工具实例
-func NewTavilyTool(apiKey string) *TavilyTool {
+// DefaultTavilyEndpoint Tavily 默认的搜索接口地址
+const DefaultTavilyEndpoint = "https://api.tavily.com/search"
+
+// NewTavilyTool 创建Tavily工具实例，endpoint 为空时使用默认地址
+func NewTavilyTool(apiKey, endpoint string) *TavilyTool {
+	if endpoint == "" {
+		endpoint = DefaultTavilyEndpoint
+	}
 	return &TavilyTool{
-		apiKey: apiKey,
+		apiKey:   apiKey,
+		endpoint: endpoint,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -38,6 +46,15 @@ func (t *TavilyTool) Description() string {
 
 // Execute 执行搜索
 func (t *TavilyTool) Execute(ctx context.Context, query string) (string, error) {
+	response, err := t.Search(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	return FormatSearchResponse(response, "Tavily"), nil
+}
+
+// Search 执行搜索并返回结构化结果
+func (t *TavilyTool) Search(ctx context.Context, query string) (*SearchResponse, error) {
 	// 构建请求
 	requestBody := map[string]interface{}{
 		"query": query,
@@ -49,13 +66,13 @@ func (t *TavilyTool) Execute(ctx context.Context, query string) (string, error)
 	
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %v", err)
+		return nil, fmt.Errorf("序列化请求失败: %v", err)
 	}
 	
 	// 创建HTTP请求
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.tavily.com/search", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", t.endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %v", err)
+		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
@@ -64,18 +81,18 @@ func (t *TavilyTool) Execute(ctx context.Context, query string) (string, error)
 	// 发送请求
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %v", err)
+		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 	
 	// 读取响应
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %v", err)
+		return nil, fmt.Errorf("读取响应失败: %v", err)
 	}
 	
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
 	}
 	
 	// 解析响应
@@ -89,7 +106,7 @@ func (t *TavilyTool) Execute(ctx context.Context, query string) (string, error)
 	}
 	
 	if err := json.Unmarshal(body, &tavilyResponse); err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		return nil, fmt.Errorf("解析响应失败: %v", err)
 	}
 	
 	// 构建搜索结果
@@ -104,17 +121,10 @@ func (t *TavilyTool) Execute(ctx context.Context, query string) (string, error)
 		})
 	}
 	
-	// 格式化输出
-	output := fmt.Sprintf("🔍 Tavily搜索结果 - 查询: %s\n\n", query)
-	for i, result := range results {
-		output += fmt.Sprintf("%d. **%s**\n", i+1, result.Title)
-		output += fmt.Sprintf("   %s\n", result.Snippet)
-		output += fmt.Sprintf("   链接: %s\n", result.URL)
-		if result.PublishedAt != "" {
-			output += fmt.Sprintf("   发布时间: %s\n", result.PublishedAt)
-		}
-		output += "\n"
-	}
-	
-	return output, nil
-} 
\ No newline at end of file
+	return &SearchResponse{
+		Query:   query,
+		Results: results,
+		Total:   len(results),
+		Source:  "tavily",
+	}, nil
+}